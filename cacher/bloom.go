@@ -0,0 +1,190 @@
+package cacher
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"time"
+
+	redigo "github.com/gomodule/redigo/redis"
+	"github.com/mazharul-islam/utils"
+)
+
+// BloomFilter answers whether an item was probably added to the filter of an owner, e.g. the profiles already shown
+// to a user, without keeping the items. It may answer true for an item never added, at the configured error rate,
+// but never false for an added item. The filters rotate daily: items are added to the filter of the day and looked
+// up in the filters of the day and of the day before, so an item is remembered for one to two days.
+type BloomFilter struct {
+	cache  CacheManager
+	name   string
+	bits   uint64
+	hashes int
+}
+
+// NewBloomFilter creates a BloomFilter sized to hold capacity items per owner and per day at the given false
+// positive rate, e.g. 1000 items at 0.01 take 1.2KB per owner and day. The rate must be between 0 and 1 excluded.
+func NewBloomFilter(cache CacheManager, name string, capacity uint, errorRate float64) (*BloomFilter, error) {
+	if !(errorRate > 0 && errorRate < 1) {
+		return nil, ErrInvalidBloomErrorRate
+	}
+
+	capacity = max(capacity, 1)
+	bits := math.Ceil(-float64(capacity) * math.Log(errorRate) / (math.Ln2 * math.Ln2))
+
+	return &BloomFilter{
+		cache:  cache,
+		name:   name,
+		bits:   uint64(max(bits, 1)),
+		hashes: max(int(math.Round(bits/float64(capacity)*math.Ln2)), 1),
+	}, nil
+}
+
+// Add adds the items to the filter of the owner.
+func (bloom *BloomFilter) Add(ctx context.Context, owner string, items ...string) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	var offsets []uint64
+	for _, item := range items {
+		offsets = append(offsets, bloom.offsets(item)...)
+	}
+
+	return bloom.cache.SetBits(ctx, bloom.key(owner, time.Now()), offsets, bloomFilterTTL)
+}
+
+// MightContain tells for each item whether it was probably added to the filter of the owner.
+func (bloom *BloomFilter) MightContain(ctx context.Context, owner string, items ...string) ([]bool, error) {
+	found := make([]bool, len(items))
+	if len(items) == 0 {
+		return found, nil
+	}
+
+	offsets := make([]uint64, 0, len(items)*bloom.hashes)
+	for _, item := range items {
+		offsets = append(offsets, bloom.offsets(item)...)
+	}
+
+	now := time.Now()
+	for _, day := range []time.Time{now, now.AddDate(0, 0, -1)} {
+		bits, err := bloom.cache.GetBits(ctx, bloom.key(owner, day), offsets)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range items {
+			found[i] = found[i] || allSet(bits[i*bloom.hashes:(i+1)*bloom.hashes])
+		}
+	}
+
+	return found, nil
+}
+
+// offsets returns the bits of the item, derived from two hashes as in "Less Hashing, Same Performance".
+func (bloom *BloomFilter) offsets(item string) []uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(item))
+
+	// fnv alone spreads similar items such as sequential ids poorly
+	sum1 := mix64(h.Sum64())
+	sum2 := mix64(sum1) | 1
+	offsets := make([]uint64, bloom.hashes)
+	for i := range offsets {
+		offsets[i] = (sum1 + uint64(i)*sum2) % bloom.bits
+	}
+
+	return offsets
+}
+
+func (bloom *BloomFilter) key(owner string, day time.Time) string {
+	return bloom.cache.CreateCacheKey(NamespaceBloom, utils.WriteStringTemplate("cache:bloom:%s:%s:%s",
+		bloom.name, owner, day.UTC().Format("20060102")))
+}
+
+// mix64 is the finalizer of murmur3.
+func mix64(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+
+	return h
+}
+
+func allSet(bits []bool) bool {
+	for _, bit := range bits {
+		if !bit {
+			return false
+		}
+	}
+
+	return true
+}
+
+// SetBits is used to set the bits at the offsets of a bitmap, creating it with the given TTL.
+func (cache *cacheManager) SetBits(ctx context.Context, key string, offsets []uint64, ttl time.Duration) (err error) {
+	if cache.skipCache() || len(offsets) == 0 {
+		return nil
+	}
+
+	ctx, span := startSpan(ctx, "SetBits", key)
+	defer func() { endSpan(span, err) }()
+
+	client, err := cache.getConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer utils.WrapCloser(client.Close)
+
+	if err := client.Send("MULTI"); err != nil {
+		return err
+	}
+
+	for _, offset := range offsets {
+		if err := client.Send("SETBIT", key, offset, 1); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Send("EXPIRE", key, int64(ttl.Seconds())); err != nil {
+		return err
+	}
+
+	_, err = redigo.DoContext(client, ctx, "EXEC")
+	return err
+}
+
+// GetBits is used to read the bits at the offsets of a bitmap, a missing bitmap has no bit set.
+func (cache *cacheManager) GetBits(ctx context.Context, key string, offsets []uint64) (bits []bool, err error) {
+	bits = make([]bool, len(offsets))
+	if cache.skipCache() || len(offsets) == 0 {
+		return bits, nil
+	}
+
+	ctx, span := startSpan(ctx, "GetBits", key)
+	defer func() { endSpan(span, err) }()
+
+	client, err := cache.getConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer utils.WrapCloser(client.Close)
+
+	for _, offset := range offsets {
+		if err := client.Send("GETBIT", key, offset); err != nil {
+			return nil, err
+		}
+	}
+
+	values, err := redigo.Int64s(receiveReplies(ctx, client, len(offsets)))
+	if err != nil {
+		return nil, err
+	}
+
+	for i, value := range values {
+		bits[i] = value == 1
+	}
+
+	return bits, nil
+}
@@ -0,0 +1,308 @@
+package cacher
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	redigo "github.com/gomodule/redigo/redis"
+	"github.com/mazharul-islam/utils"
+	"github.com/sirupsen/logrus"
+)
+
+type (
+	// circuitBreaker switches the cache manager to pass-through mode after repeated redis failures, so the
+	// requests are served from the database instead of failing. Once open it probes redis in background and
+	// closes again when redis answers.
+	circuitBreaker struct {
+		failureThreshold int64
+		probeInterval    time.Duration
+
+		failures atomic.Int64
+		open     atomic.Bool
+
+		probe     func() error
+		onRecover func()
+	}
+
+	// breakerConn reports the failures of a pooled connection to the circuit breaker
+	breakerConn struct {
+		redigo.Conn
+		breaker *circuitBreaker
+	}
+
+	// pendingInvalidations are the keys and tags invalidated while the circuit breaker is open, they are replayed
+	// once redis recovers so the values cached before the outage are not served again. When more than
+	// maxPendingInvalidations are waiting, every namespace is bumped instead.
+	pendingInvalidations struct {
+		mu       sync.Mutex
+		keys     []string
+		tags     []string
+		overflow bool
+	}
+
+	// unavailableConn is returned without context while the circuit breaker is open
+	unavailableConn struct {
+		err error
+	}
+)
+
+// SetCircuitBreaker is used to set after how many consecutive redis failures the cache is bypassed, and how often
+// redis is probed until it recovers. A threshold of zero never bypasses the cache.
+func (cache *cacheManager) SetCircuitBreaker(failureThreshold int, probeInterval time.Duration) {
+	cache.breaker.failureThreshold = int64(failureThreshold)
+	cache.breaker.probeInterval = probeInterval
+}
+
+// newCircuitBreaker creates the circuit breaker of the cache manager, probing with PING on the connection pool.
+func (cache *cacheManager) newCircuitBreaker() *circuitBreaker {
+	return &circuitBreaker{
+		failureThreshold: defaultBreakerFailureThreshold,
+		probeInterval:    defaultBreakerProbeInterval,
+		probe: func() error {
+			client := cache.connPool.Get()
+			defer func() {
+				_ = client.Close()
+			}()
+
+			_, err := client.Do("PING")
+			return err
+		},
+		onRecover: func() {
+			// invalidation messages were missed while redis was away
+			cache.localCache.purge("*")
+			cache.replayInvalidations(context.Background())
+		},
+	}
+}
+
+// skipCache tells whether redis is skipped, because caching is disabled or redis is unavailable.
+func (cache *cacheManager) skipCache() bool {
+	return cache.disableCaching || cache.breaker.isOpen()
+}
+
+// getConn returns a connection of the pool which reports its failures to the circuit breaker.
+func (cache *cacheManager) getConn(ctx context.Context) (redigo.Conn, error) {
+	if cache.breaker.isOpen() {
+		return nil, ErrCacheUnavailable
+	}
+
+	client, err := cache.connPool.GetContext(ctx)
+	cache.breaker.record(err)
+	if err != nil {
+		return nil, err
+	}
+
+	return &breakerConn{Conn: client, breaker: cache.breaker}, nil
+}
+
+// conn is the variant of getConn without context, errors are returned by the commands of the connection.
+func (cache *cacheManager) conn() redigo.Conn {
+	client, err := cache.getConn(context.Background())
+	if err != nil {
+		return unavailableConn{err: err}
+	}
+
+	return client
+}
+
+// deferInvalidation queues the keys and tags invalidated while redis is unavailable.
+func (cache *cacheManager) deferInvalidation(keys, tags []string) {
+	pending := &cache.pendingInvalidations
+	pending.mu.Lock()
+	defer pending.mu.Unlock()
+
+	if pending.overflow {
+		return
+	}
+
+	if len(pending.keys)+len(pending.tags)+len(keys)+len(tags) > maxPendingInvalidations {
+		pending.keys, pending.tags, pending.overflow = nil, nil, true
+		return
+	}
+
+	pending.keys = append(pending.keys, keys...)
+	pending.tags = append(pending.tags, tags...)
+}
+
+// replayInvalidations applies the invalidations deferred while redis was unavailable.
+func (cache *cacheManager) replayInvalidations(ctx context.Context) {
+	pending := &cache.pendingInvalidations
+	pending.mu.Lock()
+	keys, tags, overflow := pending.keys, pending.tags, pending.overflow
+	pending.keys, pending.tags, pending.overflow = nil, nil, false
+	pending.mu.Unlock()
+
+	if overflow {
+		for _, namespace := range namespaces {
+			if _, err := cache.BumpNamespaceVersion(ctx, namespace); err != nil {
+				logrus.WithField("namespace", namespace).Error(err)
+			}
+		}
+
+		return
+	}
+
+	if err := cache.DeleteByKeysCtx(ctx, utils.Unique(keys)); err != nil {
+		logrus.WithField("keys", len(keys)).Error(err)
+	}
+
+	if err := cache.InvalidateTagsCtx(ctx, utils.Unique(tags)...); err != nil {
+		logrus.WithField("tags", len(tags)).Error(err)
+	}
+}
+
+func (breaker *circuitBreaker) isOpen() bool {
+	return breaker.open.Load()
+}
+
+// record counts the consecutive connection failures, replies of redis such as errors of a command reset the count.
+func (breaker *circuitBreaker) record(err error) {
+	if !isConnectionError(err) {
+		breaker.failures.Store(0)
+		return
+	}
+
+	if breaker.failureThreshold <= 0 || breaker.failures.Add(1) < breaker.failureThreshold {
+		return
+	}
+
+	if !breaker.open.CompareAndSwap(false, true) {
+		return
+	}
+
+	logrus.WithField("failures", breaker.failures.Load()).
+		Errorf("redis is unavailable, the cache is bypassed until it recovers: %v", err)
+
+	go breaker.probeUntilRecovered()
+}
+
+func (breaker *circuitBreaker) probeUntilRecovered() {
+	ticker := time.NewTicker(breaker.probeInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := breaker.probe(); err != nil {
+			logrus.Debug(err)
+			continue
+		}
+
+		breaker.failures.Store(0)
+		breaker.open.Store(false)
+		breaker.onRecover()
+
+		logrus.Info("redis recovered, the cache is used again")
+		return
+	}
+}
+
+// isConnectionError tells whether redis could not be reached, as opposed to an error reply or a cancelled caller.
+func isConnectionError(err error) bool {
+	if err == nil || err == redigo.ErrNil || err == redigo.ErrPoolExhausted {
+		return false
+	}
+
+	var replyError redigo.Error
+	if errors.As(err, &replyError) {
+		return false
+	}
+
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// Do :nodoc:
+func (conn *breakerConn) Do(cmd string, args ...any) (any, error) {
+	reply, err := conn.Conn.Do(cmd, args...)
+	conn.breaker.record(err)
+	return reply, err
+}
+
+// DoContext :nodoc:
+func (conn *breakerConn) DoContext(ctx context.Context, cmd string, args ...any) (any, error) {
+	reply, err := redigo.DoContext(conn.Conn, ctx, cmd, args...)
+	conn.breaker.record(err)
+	return reply, err
+}
+
+// Flush :nodoc:
+func (conn *breakerConn) Flush() error {
+	err := conn.Conn.Flush()
+	conn.breaker.record(err)
+	return err
+}
+
+// Receive :nodoc:
+func (conn *breakerConn) Receive() (any, error) {
+	reply, err := conn.Conn.Receive()
+	conn.breaker.record(err)
+	return reply, err
+}
+
+// ReceiveContext :nodoc:
+func (conn *breakerConn) ReceiveContext(ctx context.Context) (any, error) {
+	reply, err := redigo.ReceiveContext(conn.Conn, ctx)
+	conn.breaker.record(err)
+	return reply, err
+}
+
+// ReceiveWithTimeout :nodoc:
+func (conn *breakerConn) ReceiveWithTimeout(timeout time.Duration) (any, error) {
+	reply, err := redigo.ReceiveWithTimeout(conn.Conn, timeout)
+	conn.breaker.record(err)
+	return reply, err
+}
+
+// DoWithTimeout :nodoc:
+func (conn *breakerConn) DoWithTimeout(timeout time.Duration, cmd string, args ...any) (any, error) {
+	reply, err := redigo.DoWithTimeout(conn.Conn, timeout, cmd, args...)
+	conn.breaker.record(err)
+	return reply, err
+}
+
+// Close :nodoc:
+func (conn unavailableConn) Close() error {
+	return nil
+}
+
+// Err :nodoc:
+func (conn unavailableConn) Err() error {
+	return conn.err
+}
+
+// Do :nodoc:
+func (conn unavailableConn) Do(string, ...any) (any, error) {
+	return nil, conn.err
+}
+
+// DoContext :nodoc:
+func (conn unavailableConn) DoContext(context.Context, string, ...any) (any, error) {
+	return nil, conn.err
+}
+
+// Send :nodoc:
+func (conn unavailableConn) Send(string, ...any) error {
+	return conn.err
+}
+
+// Flush :nodoc:
+func (conn unavailableConn) Flush() error {
+	return conn.err
+}
+
+// Receive :nodoc:
+func (conn unavailableConn) Receive() (any, error) {
+	return nil, conn.err
+}
+
+// ReceiveContext :nodoc:
+func (conn unavailableConn) ReceiveContext(context.Context) (any, error) {
+	return nil, conn.err
+}
+
+var (
+	_ redigo.ConnWithContext = (*breakerConn)(nil)
+	_ redigo.ConnWithTimeout = (*breakerConn)(nil)
+	_ redigo.ConnWithContext = unavailableConn{}
+)
@@ -1,13 +1,18 @@
 package cacher
 
 import (
+	"context"
 	"encoding/json"
 	"github.com/go-redsync/redsync/v4"
 	redigosync "github.com/go-redsync/redsync/v4/redis/redigo"
 	redigo "github.com/gomodule/redigo/redis"
+	"github.com/google/uuid"
 	"github.com/jpillora/backoff"
 	"github.com/mazharul-islam/config"
 	"github.com/mazharul-islam/utils"
+	"github.com/sirupsen/logrus"
+	"strconv"
+	"sync"
 	"time"
 )
 
@@ -16,10 +21,15 @@ var nilValue = []byte("null")
 type (
 	GetterFn func() (any, error)
 
+	// GetterCtxFn is the context aware getter function of GetOrSetCtx
+	GetterCtxFn func(ctx context.Context) (any, error)
+
 	CacheManager interface {
 		Get(key string) (any, error)
 		GetOrLock(key string) (any, *redsync.Mutex, error)
 		GetOrSet(key string, fn GetterFn, opts ...func(Item)) ([]byte, error)
+		GetMulti(keys []string) (map[string]any, error)
+		GetOrSetMulti(keys []string, fn BatchGetterFn, opts ...func(Item)) (map[string][]byte, error)
 
 		// HASH BUCKET
 		GetHashMemberOrLock(identifier string, key string) (any, *redsync.Mutex, error)
@@ -35,8 +45,9 @@ type (
 
 		Expire(string, time.Duration) error
 		ExpireMulti(map[string]time.Duration) error
-		Purge(string) error
+		Purge(string) (int64, error)
 		DeleteByKeys(keys []string) error
+		InvalidateTags(tags ...string) error
 		SetCachePrefix(string, string)
 
 		IncreaseCachedValueByOne(key string) error
@@ -52,6 +63,65 @@ type (
 		SetLockTries(int)
 		SetWaitTime(time.Duration)
 		SetDisableCaching(bool)
+		SetCircuitBreaker(failureThreshold int, probeInterval time.Duration)
+		SetCodec(Codec)
+		Marshal(v any) ([]byte, error)
+
+		// LOCAL CACHE
+		SetLocalCache(maxSize int, ttl time.Duration)
+		SetSubscriberConnectionPool(*redigo.Pool)
+		ListenInvalidation(ctx context.Context) error
+
+		// CONTEXT AWARE
+		GetCtx(ctx context.Context, key string) (any, error)
+		GetOrLockCtx(ctx context.Context, key string) (any, *redsync.Mutex, error)
+		GetOrSetCtx(ctx context.Context, key string, fn GetterCtxFn, opts ...func(Item)) ([]byte, error)
+		GetMultiCtx(ctx context.Context, keys []string) (map[string]any, error)
+		GetOrSetMultiCtx(ctx context.Context, keys []string, fn BatchGetterFn, opts ...func(Item)) (map[string][]byte, error)
+		GetHashMemberOrLockCtx(ctx context.Context, identifier string, key string) (any, *redsync.Mutex, error)
+		GetHashMemberCtx(ctx context.Context, identifier string, key string) (any, error)
+		StoreHashMemberCtx(ctx context.Context, identifier string, c Item) error
+		StoreCtx(ctx context.Context, mutex *redsync.Mutex, item Item) error
+		StoreWithoutBlockingCtx(ctx context.Context, item Item) error
+		StoreMultiWithoutBlockingCtx(ctx context.Context, items []Item) error
+		StoreNilCtx(ctx context.Context, cacheKey string) error
+		DeleteByKeysCtx(ctx context.Context, keys []string) error
+		PurgeCtx(ctx context.Context, matchString string) (int64, error)
+		InvalidateTagsCtx(ctx context.Context, tags ...string) error
+		AcquireLockCtx(ctx context.Context, key string) (*redsync.Mutex, error)
+
+		// INTROSPECTION
+		Stats() map[string]PrefixStats
+		SampleKeys(ctx context.Context, prefix string, count int) ([]KeyInfo, error)
+
+		// PURGE JOBS
+		PurgeAsync(ctx context.Context, matchString string) (PurgeJob, error)
+		GetPurgeJob(ctx context.Context, id string) (PurgeJob, error)
+		CancelPurgeJob(ctx context.Context, id string) error
+		FailOrphanedPurgeJobs(ctx context.Context) (int, error)
+
+		// BITMAPS
+		SetBits(ctx context.Context, key string, offsets []uint64, ttl time.Duration) error
+		GetBits(ctx context.Context, key string, offsets []uint64) ([]bool, error)
+
+		// SORTED SET
+		AddScore(ctx context.Context, key, member string, score float64) error
+		IncrementScore(ctx context.Context, key, member string, delta float64) (float64, error)
+		TopMembers(ctx context.Context, key string, count int64) ([]ScoredMember, error)
+		MemberRank(ctx context.Context, key, member string) (rank int64, found bool, err error)
+		MemberScores(ctx context.Context, key string, members []string) (map[string]float64, error)
+		MembersByScore(ctx context.Context, key string, min, max float64, offset, count int64) ([]ScoredMember, error)
+		CountMembers(ctx context.Context, key string) (int64, error)
+		TrimMembers(ctx context.Context, key string, size int64) (int64, error)
+
+		// VERSIONED
+		GetEnvelope(ctx context.Context, key string) (*EnvelopeMeta, error)
+
+		// NAMESPACES
+		LoadNamespaceVersions(ctx context.Context) error
+		NamespaceVersions() map[string]int64
+		CreateCacheKey(namespace, value string) string
+		BumpNamespaceVersion(ctx context.Context, namespace string) (int64, error)
 	}
 
 	cacheManager struct {
@@ -63,10 +133,30 @@ type (
 		defaultTTL     time.Duration
 		waitTime       time.Duration
 		disableCaching bool
+		codec          Codec
 
 		lockConnPool *redigo.Pool
 		lockDuration time.Duration
 		lockTries    int
+
+		// localCache optional in-process tier in front of redis, nil when disabled
+		localCache *localCache
+		instanceID string
+
+		// subscriberConnPool connections receiving the invalidation messages, without read timeout
+		subscriberConnPool *redigo.Pool
+
+		// revalidating keys being refreshed in background by this instance
+		revalidating sync.Map
+
+		stats   cacheStats
+		breaker *circuitBreaker
+
+		// pendingInvalidations invalidations received while the circuit breaker is open
+		pendingInvalidations pendingInvalidations
+
+		// keyspace namespace versions appended to the keys built by CreateCacheKey
+		keyspace *cacheKeyspace
 	}
 
 	itemWithKey struct {
@@ -97,7 +187,7 @@ func (errorKey *errorWithKey) Error() string {
 
 // ConstructCacheManager is used to create an instance of CacheManager with default configuration.
 func ConstructCacheManager() CacheManager {
-	return &cacheManager{
+	cache := &cacheManager{
 		defaultTTL:     defaultTTL,
 		nilTTL:         defaultNilTTL,
 		prefixCacheKey: defaultPrefixCacheKey,
@@ -106,40 +196,74 @@ func ConstructCacheManager() CacheManager {
 		lockTries:      defaultLockTries,
 		waitTime:       defaultWaitTime,
 		disableCaching: false,
+		codec:          NewJSONCodec(),
+		instanceID:     uuid.NewString(),
+		keyspace:       newCacheKeyspace(),
 	}
+	cache.breaker = cache.newCircuitBreaker()
+
+	return cache
 }
 
 // Get is used to retrieve an item stored in the cache based on the key.
 func (cache *cacheManager) Get(key string) (cachedItem any, err error) {
-	if cache.disableCaching {
+	return cache.GetCtx(context.Background(), key)
+}
+
+// GetCtx is the context aware variant of Get.
+func (cache *cacheManager) GetCtx(ctx context.Context, key string) (cachedItem any, err error) {
+	if cache.skipCache() {
 		return
 	}
 
-	cachedItem, err = get(cache.connPool.Get(), key)
+	ctx, span := startSpan(ctx, "Get", key)
+	defer func() { endSpan(span, err) }()
+
+	cachedItem, err = cache.getCachedItem(ctx, key)
 	if err != nil && err != ErrKeyNotExist && err != redigo.ErrNil || cachedItem != nil {
+		if err == nil {
+			cache.recordLookup(key, cachedItem)
+		}
 		return
 	}
 
+	cache.recordLookup(key, nil)
+
 	return nil, nil
 }
 
 // GetOrLock is used to retrieve an item from the cache based on the key. If the item is not found,
 // it will acquire a lock and wait for the item to be available in the cache.
 func (cache *cacheManager) GetOrLock(key string) (cachedItem any, mutex *redsync.Mutex, err error) {
-	if cache.disableCaching {
+	return cache.GetOrLockCtx(context.Background(), key)
+}
+
+// GetOrLockCtx is the context aware variant of GetOrLock, waiting for the lock stops when the context is done.
+func (cache *cacheManager) GetOrLockCtx(ctx context.Context, key string) (cachedItem any, mutex *redsync.Mutex, err error) {
+	if cache.skipCache() {
 		return
 	}
 
-	cachedItem, err = get(cache.connPool.Get(), key)
+	ctx, span := startSpan(ctx, "GetOrLock", key)
+	defer func() { endSpan(span, err) }()
+
+	cachedItem, err = cache.getCachedItem(ctx, key)
 	if err != nil && err != ErrKeyNotExist && err != redigo.ErrNil || cachedItem != nil {
+		if err == nil {
+			cache.recordLookup(key, cachedItem)
+		}
 		return
 	}
 
-	mutex, err = cache.AcquireLock(key)
+	cache.recordLookup(key, nil)
+
+	mutex, err = cache.AcquireLockCtx(ctx, key)
 	if err == nil {
 		return
 	}
 
+	cache.recordLockWait(key)
+
 	startTime := time.Now()
 	for {
 		backoffRetries := &backoff.Backoff{
@@ -148,11 +272,11 @@ func (cache *cacheManager) GetOrLock(key string) (cachedItem any, mutex *redsync
 			Jitter: true,
 		}
 
-		if !cache.isLocked(key) {
-			cachedItem, err = get(cache.connPool.Get(), key)
+		if !cache.isLocked(ctx, key) {
+			cachedItem, err = cache.getCachedItem(ctx, key)
 			if err != nil {
 				if err == ErrKeyNotExist {
-					mutex, err = cache.AcquireLock(key)
+					mutex, err = cache.AcquireLockCtx(ctx, key)
 					if err == nil {
 						return nil, mutex, nil
 					}
@@ -169,18 +293,29 @@ func (cache *cacheManager) GetOrLock(key string) (cachedItem any, mutex *redsync
 			break
 		}
 
-		time.Sleep(backoffRetries.Duration())
+		if err = sleepWithContext(ctx, backoffRetries.Duration()); err != nil {
+			return nil, nil, err
+		}
 	}
 
+	cache.recordWaitTooLong(key)
 	return nil, nil, ErrWaitTooLong
 }
 
 // GetOrSet is used to retrieve a value from the cache based on a given key.
 // If the value is not found in the cache, it will be fetched using a getter function and then stored in the cache for future use.
-// The function also provides options for customizing the caching behavior through optional functional parameters opts
+// The function also provides options for customizing the caching behavior through optional functional parameters opts.
+// The returned value is the JSON document of the value, whichever codec it is stored with.
 func (cache *cacheManager) GetOrSet(key string, fn GetterFn, opts ...func(Item)) (res []byte, err error) {
-	if cache.disableCaching {
-		myResp, err := fn()
+	return cache.GetOrSetCtx(context.Background(), key, func(context.Context) (any, error) {
+		return fn()
+	}, opts...)
+}
+
+// GetOrSetCtx is the context aware variant of GetOrSet, the context is passed to the getter function.
+func (cache *cacheManager) GetOrSetCtx(ctx context.Context, key string, fn GetterCtxFn, opts ...func(Item)) (res []byte, err error) {
+	if cache.skipCache() {
+		myResp, err := fn(ctx)
 		if err != nil {
 			return nil, err
 		}
@@ -188,7 +323,33 @@ func (cache *cacheManager) GetOrSet(key string, fn GetterFn, opts ...func(Item))
 		return json.Marshal(myResp)
 	}
 
-	cachedValue, mu, err := cache.GetOrLock(key)
+	ctx, span := startSpan(ctx, "GetOrSet", key)
+	defer func() { endSpan(span, err) }()
+
+	res, err = cache.getOrSet(ctx, key, fn, opts)
+	if err != nil || res == nil {
+		return nil, err
+	}
+
+	return decodeJSON(res)
+}
+
+// getOrSet returns the cached payload of the key, as stored, or loads and stores it.
+func (cache *cacheManager) getOrSet(ctx context.Context, key string, fn GetterCtxFn, opts []func(Item)) (res []byte, err error) {
+
+	settings := NewItem(key, nil)
+	for _, o := range opts {
+		o(settings)
+	}
+
+	if settings.GetSoftTTL() > 0 {
+		staleValue, found, err := cache.getStale(ctx, key, fn, opts, settings.GetEarlyExpiryBeta())
+		if err != nil || found {
+			return staleValue, err
+		}
+	}
+
+	cachedValue, mu, err := cache.GetOrLockCtx(ctx, key)
 	if err != nil {
 		return
 	}
@@ -206,48 +367,89 @@ func (cache *cacheManager) GetOrSet(key string, fn GetterFn, opts ...func(Item))
 		return
 	}
 
+	return cache.loadAndStore(ctx, mu, key, fn, opts)
+}
+
+// loadAndStore calls the getter function and stores its result while holding the mutex.
+func (cache *cacheManager) loadAndStore(ctx context.Context, mu *redsync.Mutex, key string, fn GetterCtxFn, opts []func(Item)) ([]byte, error) {
 	defer SafeUnlock(mu)
-	item, err := fn()
+
+	stopWatchdog := watchLock(ctx, mu, cache.lockDuration)
+	startTime := time.Now()
+	item, err := fn(ctx)
+	if lockErr := stopWatchdog(); lockErr != nil {
+		// the loaded value is still stored, but a concurrent loader may have run
+		cache.recordLockLost(key)
+		logrus.WithField("cacheKey", key).Error(lockErr)
+	}
+
 	if err != nil {
-		return
+		return nil, err
 	}
 
 	if item == nil {
-		_ = cache.StoreNil(key)
-		return
+		_ = cache.StoreNilCtx(ctx, key)
+		return nil, nil
 	}
 
-	cachedValue, err = json.Marshal(item)
+	cachedValue, err := cache.Marshal(item)
 	if err != nil {
-		return
+		return nil, err
 	}
 
 	cacheItem := NewItem(key, cachedValue)
+	applyVersion(cacheItem, item)
 	for _, o := range opts {
 		o(cacheItem)
 	}
-	_ = cache.Store(mu, cacheItem)
-	return cachedValue.([]byte), nil
+
+	softTTL := cacheItem.GetSoftTTL()
+	if softTTL > 0 {
+		cache.keepPastSoftTTL(cacheItem)
+	}
+
+	// the meta of a value refused by the version check would describe the newer value
+	delta := time.Since(startTime)
+	if err := cache.StoreCtx(ctx, mu, cacheItem); err == nil && softTTL > 0 {
+		cache.storeStaleMeta(ctx, cacheItem, delta)
+	}
+
+	return cachedValue, nil
 }
 
 // GetHashMemberOrLock :nodoc:
 func (cache *cacheManager) GetHashMemberOrLock(identifier string, key string) (cachedItem any, mutex *redsync.Mutex, err error) {
-	if cache.disableCaching {
+	return cache.GetHashMemberOrLockCtx(context.Background(), identifier, key)
+}
+
+// GetHashMemberOrLockCtx is the context aware variant of GetHashMemberOrLock.
+func (cache *cacheManager) GetHashMemberOrLockCtx(ctx context.Context, identifier string, key string) (cachedItem any, mutex *redsync.Mutex, err error) {
+	if cache.skipCache() {
 		return
 	}
 
 	lockKey := utils.WriteStringTemplate("%s:%s", identifier, key)
 
-	cachedItem, err = cache.GetHashMember(identifier, key)
+	ctx, span := startSpan(ctx, "GetHashMemberOrLock", lockKey)
+	defer func() { endSpan(span, err) }()
+
+	cachedItem, err = cache.GetHashMemberCtx(ctx, identifier, key)
 	if err != nil && err != redigo.ErrNil && err != ErrKeyNotExist || cachedItem != nil {
+		if err == nil {
+			cache.recordLookup(identifier, cachedItem)
+		}
 		return
 	}
 
-	mutex, err = cache.AcquireLock(lockKey)
+	cache.recordLookup(identifier, nil)
+
+	mutex, err = cache.AcquireLockCtx(ctx, lockKey)
 	if err == nil {
 		return // nolint:nilerr
 	}
 
+	cache.recordLockWait(identifier)
+
 	start := time.Now()
 	for {
 		b := &backoff.Backoff{
@@ -256,11 +458,11 @@ func (cache *cacheManager) GetHashMemberOrLock(identifier string, key string) (c
 			Jitter: true,
 		}
 
-		if !cache.isLocked(lockKey) {
-			cachedItem, err = cache.GetHashMember(identifier, key)
+		if !cache.isLocked(ctx, lockKey) {
+			cachedItem, err = cache.GetHashMemberCtx(ctx, identifier, key)
 			if err != nil {
 				if err == ErrKeyNotExist {
-					mutex, err = cache.AcquireLock(lockKey)
+					mutex, err = cache.AcquireLockCtx(ctx, lockKey)
 					if err == nil {
 						return nil, mutex, nil
 					}
@@ -278,33 +480,52 @@ func (cache *cacheManager) GetHashMemberOrLock(identifier string, key string) (c
 			break
 		}
 
-		time.Sleep(b.Duration())
+		if err = sleepWithContext(ctx, b.Duration()); err != nil {
+			return nil, nil, err
+		}
 	}
 
+	cache.recordWaitTooLong(identifier)
 	return nil, nil, ErrWaitTooLong
 }
 
 // GetHashMember :nodoc:
 func (cache *cacheManager) GetHashMember(identifier string, key string) (value any, err error) {
-	if cache.disableCaching {
+	return cache.GetHashMemberCtx(context.Background(), identifier, key)
+}
+
+// GetHashMemberCtx is the context aware variant of GetHashMember.
+func (cache *cacheManager) GetHashMemberCtx(ctx context.Context, identifier string, key string) (value any, err error) {
+	if cache.skipCache() {
 		return
 	}
 
-	client := cache.connPool.Get()
-	defer func() {
-		_ = client.Close()
-	}()
+	client, err := cache.getConn(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	return getHashMember(client, identifier, key)
+	return getHashMember(ctx, client, identifier, key)
 }
 
 // StoreHashMember :nodoc:
 func (cache *cacheManager) StoreHashMember(identifier string, c Item) (err error) {
-	if cache.disableCaching {
+	return cache.StoreHashMemberCtx(context.Background(), identifier, c)
+}
+
+// StoreHashMemberCtx is the context aware variant of StoreHashMember.
+func (cache *cacheManager) StoreHashMemberCtx(ctx context.Context, identifier string, c Item) (err error) {
+	if cache.skipCache() {
 		return nil
 	}
 
-	client := cache.connPool.Get()
+	ctx, span := startSpan(ctx, "StoreHashMember", identifier)
+	defer func() { endSpan(span, err) }()
+
+	client, err := cache.getConn(ctx)
+	if err != nil {
+		return err
+	}
 	defer func() {
 		_ = client.Close()
 	}()
@@ -313,82 +534,132 @@ func (cache *cacheManager) StoreHashMember(identifier string, c Item) (err error
 	if err != nil {
 		return err
 	}
-	_, err = client.Do("HSET", identifier, c.GetKey(), c.GetValue())
+	_, err = redigo.DoContext(client, ctx, "HSET", identifier, c.GetKey(), c.GetValue())
 	if err != nil {
 		return err
 	}
-	_, err = client.Do("EXPIRE", identifier, cache.decideCacheTTL(c))
+	_, err = redigo.DoContext(client, ctx, "EXPIRE", identifier, cache.decideCacheTTL(c))
 	if err != nil {
 		return err
 	}
 
-	_, err = client.Do("EXEC")
-	return
+	_, err = redigo.DoContext(client, ctx, "EXEC")
+	if err != nil {
+		return err
+	}
+
+	// the whole bucket is tagged, members share the bucket expiration
+	return cache.tagItem(ctx, client, identifier, c.GetTags(), cache.decideCacheTTL(c))
 }
 
 // Store is used to store an item in the cache with an optional mutex lock.
 func (cache *cacheManager) Store(mutex *redsync.Mutex, item Item) error {
-	if cache.disableCaching {
+	return cache.StoreCtx(context.Background(), mutex, item)
+}
+
+// StoreCtx is the context aware variant of Store.
+func (cache *cacheManager) StoreCtx(ctx context.Context, mutex *redsync.Mutex, item Item) error {
+	if cache.skipCache() {
 		return nil
 	}
 	defer SafeUnlock(mutex)
 
-	client := cache.connPool.Get()
-	defer utils.WrapCloser(client.Close)
-
-	_, err := client.Do("SETEX", item.GetKey(), cache.decideCacheTTL(item), item.GetValue())
-	return err
+	return cache.StoreWithoutBlockingCtx(ctx, item)
 }
 
 // StoreWithoutBlocking is used to store an item in the cache without acquiring a lock.
 func (cache *cacheManager) StoreWithoutBlocking(item Item) error {
-	if cache.disableCaching {
+	return cache.StoreWithoutBlockingCtx(context.Background(), item)
+}
+
+// StoreWithoutBlockingCtx is the context aware variant of StoreWithoutBlocking.
+func (cache *cacheManager) StoreWithoutBlockingCtx(ctx context.Context, item Item) (err error) {
+	if cache.skipCache() {
 		return nil
 	}
 
-	client := cache.connPool.Get()
+	ctx, span := startSpan(ctx, "Store", item.GetKey())
+	defer func() { endSpan(span, err) }()
+
+	client, err := cache.getConn(ctx)
+	if err != nil {
+		return err
+	}
 	defer utils.WrapCloser(client.Close)
 
-	_, err := client.Do("SETEX", item.GetKey(), cache.decideCacheTTL(item), item.GetValue())
-	return err
+	if version, ok := item.GetVersion(); ok {
+		err = cache.compareAndSet(ctx, client, item, version)
+	} else {
+		_, err = redigo.DoContext(client, ctx, "SETEX", item.GetKey(), cache.decideCacheTTL(item), item.GetValue())
+	}
+
+	if err != nil {
+		return err
+	}
+
+	cache.invalidateLocal(item.GetKey())
+	return cache.tagItem(ctx, client, item.GetKey(), item.GetTags(), cache.decideCacheTTL(item))
 }
 
 // StoreMultiWithoutBlocking is used to store multiple items in the cache without acquiring locks.
 func (cache *cacheManager) StoreMultiWithoutBlocking(items []Item) error {
-	if cache.disableCaching {
+	return cache.StoreMultiWithoutBlockingCtx(context.Background(), items)
+}
+
+// StoreMultiWithoutBlockingCtx is the context aware variant of StoreMultiWithoutBlocking.
+func (cache *cacheManager) StoreMultiWithoutBlockingCtx(ctx context.Context, items []Item) (err error) {
+	if cache.skipCache() {
 		return nil
 	}
 
-	client := cache.connPool.Get()
+	ctx, span := startSpan(ctx, "StoreMulti", "")
+	defer func() { endSpan(span, err) }()
+
+	client, err := cache.getConn(ctx)
+	if err != nil {
+		return err
+	}
 	defer utils.WrapCloser(client.Close)
 
-	if err := client.Send("MULTI"); err != nil {
+	// the items may live in several cluster slots, they are written in a pipeline instead of a transaction.
+	// versioned items holding an older version than the cached one are skipped
+	for _, item := range items {
+		if version, ok := item.GetVersion(); ok {
+			err = compareAndSetScript.Send(client, item.GetKey(), strconv.FormatUint(version, 10),
+				envelopeValue(item, version), cache.decideCacheTTL(item))
+		} else {
+			err = client.Send("SETEX", item.GetKey(), cache.decideCacheTTL(item), item.GetValue())
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if _, err := receiveReplies(ctx, client, len(items)); err != nil {
 		return err
 	}
 
+	cache.invalidateLocal(itemKeys(items)...)
 	for _, item := range items {
-		if err := client.Send("SETEX", item.GetKey(), cache.decideCacheTTL(item), item.GetValue()); err != nil {
+		if err := cache.tagItem(ctx, client, item.GetKey(), item.GetTags(), cache.decideCacheTTL(item)); err != nil {
 			return err
 		}
 	}
 
-	_, err := client.Do("EXEC")
-	return err
+	return nil
 }
 
 // StoreMultiPersist is used to store multiple items in the cache and persist them indefinitely.
 func (cache *cacheManager) StoreMultiPersist(items []Item) error {
-	if cache.disableCaching {
+	if cache.skipCache() {
 		return nil
 	}
 
-	client := cache.connPool.Get()
+	client := cache.conn()
 	defer utils.WrapCloser(client.Close)
 
-	if err := client.Send("MULTI"); err != nil {
-		return err
-	}
-
+	// the items may live in several cluster slots, they are written in a pipeline instead of a transaction
 	for _, item := range items {
 		if err := client.Send("SET", item.GetKey(), item.GetValue()); err != nil {
 			return err
@@ -399,15 +670,30 @@ func (cache *cacheManager) StoreMultiPersist(items []Item) error {
 		}
 	}
 
-	_, err := client.Do("EXEC")
-	return err
+	if _, err := receiveReplies(context.Background(), client, 2*len(items)); err != nil {
+		return err
+	}
+
+	cache.invalidateLocal(itemKeys(items)...)
+	for _, item := range items {
+		if err := cache.tagItem(context.Background(), client, item.GetKey(), item.GetTags(), 0); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // StoreNil is used to store a nil value in the cache with a default time-to-live (TTL).
 func (cache *cacheManager) StoreNil(cacheKey string) error {
+	return cache.StoreNilCtx(context.Background(), cacheKey)
+}
+
+// StoreNilCtx is the context aware variant of StoreNil.
+func (cache *cacheManager) StoreNilCtx(ctx context.Context, cacheKey string) error {
 	item := NewItemWithCustomTTL(cacheKey, nilValue, cache.nilTTL)
 
-	return cache.StoreWithoutBlocking(item)
+	return cache.StoreWithoutBlockingCtx(ctx, item)
 }
 
 // StoreNilWithCustomTTL is used to store a nil value in the cache with a custom time-to-live (TTL).
@@ -419,11 +705,11 @@ func (cache *cacheManager) StoreNilWithCustomTTL(cacheKey string, customTTL time
 
 // Expire is used to set an expiration time for a cache item based on the key.
 func (cache *cacheManager) Expire(key string, duration time.Duration) (err error) {
-	if cache.disableCaching {
+	if cache.skipCache() {
 		return nil
 	}
 
-	client := cache.connPool.Get()
+	client := cache.conn()
 	defer utils.WrapCloser(client.Close)
 
 	_, err = client.Do("EXPIRE", key, int64(duration.Seconds()))
@@ -432,84 +718,49 @@ func (cache *cacheManager) Expire(key string, duration time.Duration) (err error
 
 // ExpireMulti is used to set expiration times for multiple cache items based on their keys.
 func (cache *cacheManager) ExpireMulti(items map[string]time.Duration) error {
-	if cache.disableCaching {
+	if cache.skipCache() {
 		return nil
 	}
 
-	client := cache.connPool.Get()
+	client := cache.conn()
 	defer utils.WrapCloser(client.Close)
 
-	if err := client.Send("MULTI"); err != nil {
-		return err
-	}
-
+	// the keys may live in several cluster slots, they are expired in a pipeline instead of a transaction
 	for key, duration := range items {
 		if err := client.Send("EXPIRE", key, int64(duration.Seconds())); err != nil {
 			return err
 		}
 	}
 
-	_, err := client.Do("EXEC")
+	_, err := receiveReplies(context.Background(), client, len(items))
 	return err
 }
 
-// Purge is used to remove all cache items that match a given pattern.
-func (cache *cacheManager) Purge(matchString string) error {
-	if cache.disableCaching {
-		return nil
-	}
-
-	client := cache.connPool.Get()
-	defer utils.WrapCloser(client.Close)
-
-	var cursor any
-	var stop []uint8
-	cursor = "0"
-	delCount := 0
-	for {
-		res, err := redigo.Values(client.Do("SCAN", cursor, "MATCH", matchString, "COUNT", 500000))
-		if err != nil {
-			return err
-		}
-
-		stop = res[0].([]uint8)
-		if foundKeys, ok := res[1].([]any); ok {
-			if len(foundKeys) > 0 {
-				err = client.Send("DEL", foundKeys...)
-				if err != nil {
-					return err
-				}
-
-				delCount++
-			}
-
-			// ascii for '0' is 48
-			if stop[0] == 48 {
-				break
-			}
-		}
-
-		cursor = res[0]
-	}
-
-	if delCount > 0 {
-		_ = client.Flush()
-	}
-
-	return nil
-}
-
 // DeleteByKeys is used to delete cache items based on their keys.
 func (cache *cacheManager) DeleteByKeys(keys []string) error {
-	if cache.disableCaching {
+	return cache.DeleteByKeysCtx(context.Background(), keys)
+}
+
+// DeleteByKeysCtx is the context aware variant of DeleteByKeys.
+func (cache *cacheManager) DeleteByKeysCtx(ctx context.Context, keys []string) (err error) {
+	if cache.disableCaching || len(keys) <= 0 {
 		return nil
 	}
 
-	if len(keys) <= 0 {
+	// the keys are deleted once redis recovers
+	if cache.breaker.isOpen() {
+		cache.deferInvalidation(keys, nil)
+		cache.localCache.delete(keys...)
 		return nil
 	}
 
-	client := cache.connPool.Get()
+	ctx, span := startSpan(ctx, "DeleteByKeys", "")
+	defer func() { endSpan(span, err) }()
+
+	client, err := cache.getConn(ctx)
+	if err != nil {
+		return err
+	}
 	defer utils.WrapCloser(client.Close)
 
 	var redisKeys []any
@@ -517,12 +768,18 @@ func (cache *cacheManager) DeleteByKeys(keys []string) error {
 		redisKeys = append(redisKeys, key)
 	}
 
-	_, err := client.Do("DEL", redisKeys...)
+	_, err = redigo.DoContext(client, ctx, "DEL", redisKeys...)
+	cache.invalidateLocal(keys...)
 	return err
 }
 
 // AcquireLock is used to acquire a lock on a cache item based on the key.
 func (cache *cacheManager) AcquireLock(key string) (*redsync.Mutex, error) {
+	return cache.AcquireLockCtx(context.Background(), key)
+}
+
+// AcquireLockCtx is the context aware variant of AcquireLock.
+func (cache *cacheManager) AcquireLockCtx(ctx context.Context, key string) (*redsync.Mutex, error) {
 	pool := redigosync.NewPool(cache.lockConnPool)
 
 	mutex := redsync.New(pool).NewMutex(
@@ -531,7 +788,7 @@ func (cache *cacheManager) AcquireLock(key string) (*redsync.Mutex, error) {
 		redsync.WithTries(cache.lockTries),
 	)
 
-	return mutex, mutex.Lock()
+	return mutex, mutex.LockContext(ctx)
 }
 
 // SetNilTTL is used to set the time-to-live (TTL) for nil values stored in the cache.
@@ -545,6 +802,13 @@ func (cache *cacheManager) SetConnectionPool(pool *redigo.Pool) {
 
 	//	by default, lock connection pool use same connection with primary default connection
 	cache.lockConnPool = pool
+	cache.subscriberConnPool = pool
+}
+
+// SetSubscriberConnectionPool is used to set the connection pool receiving the invalidation messages.
+// Its connections must not have a read timeout, see database.InitializeRedigoSubscriberConnectionPool.
+func (cache *cacheManager) SetSubscriberConnectionPool(pool *redigo.Pool) {
+	cache.subscriberConnPool = pool
 }
 
 // SetLockConnectionPool is used to set the connection pool for lock acquisition in the cache manager.
@@ -567,24 +831,47 @@ func (cache *cacheManager) SetWaitTime(duration time.Duration) {
 	cache.waitTime = duration
 }
 
+// SetLocalCache enables the in-process cache tier in front of redis, holding at most maxSize items for the ttl.
+// Run ListenInvalidation to keep it coherent with the other replicas.
+func (cache *cacheManager) SetLocalCache(maxSize int, ttl time.Duration) {
+	if maxSize <= 0 || ttl <= 0 {
+		cache.localCache = nil
+		return
+	}
+
+	cache.localCache = newLocalCache(maxSize, ttl)
+}
+
 // SetDisableCaching is used to enable or disable caching in the cache manager.
 func (cache *cacheManager) SetDisableCaching(disableCaching bool) {
 	cache.disableCaching = disableCaching
 }
 
+// SetCodec is used to set the codec encoding the values cached by GetOrSet and Marshal.
+// Values written with the previous codec stay readable through their format header.
+func (cache *cacheManager) SetCodec(c Codec) {
+	cache.codec = c
+}
+
+// Marshal is used to encode a value with the codec of the cache manager, prepending the format header.
+func (cache *cacheManager) Marshal(v any) ([]byte, error) {
+	return Marshal(cache.codec, v)
+}
+
 // IncreaseCachedValueByOne will increments the number stored at key by one.
 // If the key does not exist, it is set to 0 before performing the operation
 func (cache *cacheManager) IncreaseCachedValueByOne(key string) error {
-	if cache.disableCaching {
+	if cache.skipCache() {
 		return nil
 	}
 
-	client := cache.connPool.Get()
+	client := cache.conn()
 	defer func() {
 		_ = client.Close()
 	}()
 
 	_, err := client.Do("INCR", key)
+	cache.invalidateLocal(key)
 	return err
 }
 
@@ -601,7 +888,7 @@ func (cache *cacheManager) SetDefaultTTL(duration time.Duration) {
 
 // CheckKeyExist is used to check if a cache key exists.
 func (cache *cacheManager) CheckKeyExist(key string) (value bool, err error) {
-	client := cache.connPool.Get()
+	client := cache.conn()
 	defer utils.WrapCloser(client.Close)
 
 	val, err := client.Do("EXISTS", key)
@@ -613,6 +900,25 @@ func (cache *cacheManager) CheckKeyExist(key string) (value bool, err error) {
 	return
 }
 
+// getCachedItem is used to retrieve an item from the local cache, falling back to redis.
+func (cache *cacheManager) getCachedItem(ctx context.Context, key string) (any, error) {
+	if cachedItem, ok := cache.localCache.get(key); ok {
+		return cachedItem, nil
+	}
+
+	client, err := cache.getConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cachedItem, err := get(ctx, client, key)
+	if err == nil && cachedItem != nil {
+		cache.localCache.set(key, cachedItem)
+	}
+
+	return cachedItem, err
+}
+
 // decideCacheTTL is used to determine the time-to-live (TTL) for a cache item.
 func (cache *cacheManager) decideCacheTTL(c Item) (ttl int64) {
 	if ttl = c.GetTTLInt64(); ttl > 0 {
@@ -623,11 +929,14 @@ func (cache *cacheManager) decideCacheTTL(c Item) (ttl int64) {
 }
 
 // isLocked is used to check if a cache item is locked.
-func (cache *cacheManager) isLocked(key string) bool {
-	client := cache.lockConnPool.Get()
+func (cache *cacheManager) isLocked(ctx context.Context, key string) bool {
+	client, err := cache.lockConnPool.GetContext(ctx)
+	if err != nil {
+		return false
+	}
 	defer utils.WrapCloser(client.Close)
 
-	reply, err := client.Do("GET", "lock:"+key)
+	reply, err := redigo.DoContext(client, ctx, "GET", "lock:"+key)
 	if err != nil || reply == nil {
 		return false
 	}
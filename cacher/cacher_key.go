@@ -2,10 +2,37 @@ package cacher
 
 import "github.com/mazharul-islam/utils"
 
-func GetCustomerCacheKeyByID(customerID uint) string {
-	return createCacheKey(utils.WriteStringTemplate("cache:object:customer:id:%d", customerID))
+func GetCustomerCacheKeyByID(cache CacheManager, customerID uint) string {
+	return cache.CreateCacheKey(NamespaceCustomer, utils.WriteStringTemplate("cache:object:customer:id:%d", customerID))
 }
 
-func GetUserCacheKeyByID(id uint) string {
-	return createCacheKey(utils.WriteStringTemplate("cache:object:user:id:%d", id))
+func GetUserCacheKeyByID(cache CacheManager, id uint) string {
+	return cache.CreateCacheKey(NamespaceUser, utils.WriteStringTemplate("cache:object:user:id:%d", id))
+}
+
+func GetUserCacheTagByID(id uint) string {
+	return utils.WriteStringTemplate("user:%d", id)
+}
+
+// GetUserCriteriaCacheBucket is the hash bucket of the pages of a user list filter, one member per page
+func GetUserCriteriaCacheBucket(cache CacheManager, filter string) string {
+	return cache.CreateCacheKey(NamespaceUser, utils.WriteStringTemplate("cache:bucket:user:criteria:%s", filter))
+}
+
+const (
+	// UserCriteriaAgeBucket is the width in years of the age buckets the user list pages are tagged with
+	UserCriteriaAgeBucket = 10
+	// UserCriteriaAny stands for a filter a user list does not use
+	UserCriteriaAny = "*"
+)
+
+// GetUserCriteriaCacheTag tags the user list pages which may hold the users of the gender and the age bucket, a
+// write only invalidates the pages of the buckets the written users were and are in.
+func GetUserCriteriaCacheTag(gender, ageBucket string) string {
+	return utils.WriteStringTemplate("user:criteria:gender:%s:age:%s", gender, ageBucket)
+}
+
+// GetUserPopularityLeaderboardKey is the sorted set of the users scored by the likes they received
+func GetUserPopularityLeaderboardKey(cache CacheManager) string {
+	return cache.CreateCacheKey(NamespaceLeaderboard, "cache:leaderboard:user:popularity")
 }
@@ -0,0 +1,422 @@
+package cacher
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	redigo "github.com/gomodule/redigo/redis"
+)
+
+type testUser struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func (user testUser) CacheVersion() uint64 {
+	return uint64(user.UpdatedAt.UnixMicro())
+}
+
+func newTestCacheManager(t *testing.T) (*cacheManager, *miniredis.Miniredis) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	pool := &redigo.Pool{
+		Dial: func() (redigo.Conn, error) {
+			return redigo.Dial("tcp", server.Addr())
+		},
+	}
+	t.Cleanup(func() { _ = pool.Close() })
+
+	cache := ConstructCacheManager().(*cacheManager)
+	cache.SetConnectionPool(pool)
+
+	return cache, server
+}
+
+func newTestItem(key string, value any, opts ...func(Item)) Item {
+	item := NewItem(key, value)
+	for _, o := range opts {
+		o(item)
+	}
+
+	return item
+}
+
+func TestGetOrSetReturnsJSON(t *testing.T) {
+	cache, _ := newTestCacheManager(t)
+	cache.SetCodec(NewGzipCodec(NewMsgpackCodec()))
+
+	loads := 0
+	load := func() (any, error) {
+		loads++
+		return testUser{ID: 1, Name: "alice", UpdatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}, nil
+	}
+
+	for i := 0; i < 2; i++ {
+		value, err := cache.GetOrSet("user:1", load)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if want := `{"id":1,"name":"alice","updatedAt":"2024-01-02T03:04:05Z"}`; string(value) != want {
+			t.Errorf("GetOrSet = %s, want %s", value, want)
+		}
+	}
+
+	if loads != 1 {
+		t.Errorf("getter called %d times, want 1", loads)
+	}
+}
+
+func TestStoreVersionedRejectsOlderVersion(t *testing.T) {
+	cache, _ := newTestCacheManager(t)
+	ctx := context.Background()
+
+	now := time.Now()
+	store := func(user testUser) error {
+		value, err := cache.Marshal(user)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return cache.StoreWithoutBlockingCtx(ctx, newTestItem("user:1", value, WithVersion(user.CacheVersion())))
+	}
+
+	if err := store(testUser{ID: 1, Name: "new", UpdatedAt: now}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store(testUser{ID: 1, Name: "old", UpdatedAt: now.Add(-time.Second)}); err != ErrStaleVersion {
+		t.Errorf("storing an older version returned %v, want %v", err, ErrStaleVersion)
+	}
+
+	user, found, err := NewTyped[testUser](cache).Get(ctx, "user:1")
+	if err != nil || !found {
+		t.Fatalf("Get = %v, %v", found, err)
+	}
+
+	if user.Name != "new" {
+		t.Errorf("cached name = %s, want the newer version", user.Name)
+	}
+
+	meta, err := cache.GetEnvelope(ctx, "user:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if meta == nil || meta.Version != uint64(now.UnixMicro()) {
+		t.Errorf("envelope = %+v, want version %d", meta, now.UnixMicro())
+	}
+}
+
+func TestInvalidateTags(t *testing.T) {
+	cache, server := newTestCacheManager(t)
+	ctx := context.Background()
+
+	items := []Item{
+		newTestItem("user:1", "1", WithTags("team:a")),
+		newTestItem("user:2", "2", WithTags("team:a", "team:b")),
+		newTestItem("user:3", "3", WithTags("team:b")),
+	}
+	for _, item := range items {
+		if err := cache.StoreWithoutBlockingCtx(ctx, item); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := cache.InvalidateTagsCtx(ctx, "team:a"); err != nil {
+		t.Fatal(err)
+	}
+
+	for key, want := range map[string]bool{"user:1": false, "user:2": false, "user:3": true} {
+		if server.Exists(key) != want {
+			t.Errorf("%s exists = %v, want %v", key, !want, want)
+		}
+	}
+}
+
+func TestPurge(t *testing.T) {
+	cache, server := newTestCacheManager(t)
+
+	for _, key := range []string{"user:1", "user:2/a", "match:1"} {
+		if err := server.Set(key, "1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deleted, err := cache.Purge("user:*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if deleted != 2 || server.Exists("user:2/a") || !server.Exists("match:1") {
+		t.Errorf("Purge deleted %d keys, left %v", deleted, server.Keys())
+	}
+}
+
+func TestBloomFilter(t *testing.T) {
+	cache, _ := newTestCacheManager(t)
+	ctx := context.Background()
+
+	for _, errorRate := range []float64{0, 1, -0.5, 2} {
+		if _, err := NewBloomFilter(cache, "seen", 100, errorRate); err != ErrInvalidBloomErrorRate {
+			t.Errorf("NewBloomFilter with error rate %v returned %v, want %v", errorRate, err, ErrInvalidBloomErrorRate)
+		}
+	}
+
+	bloom, err := NewBloomFilter(cache, "seen", 100, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := bloom.Add(ctx, "user:1", "a", "b"); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := bloom.MightContain(ctx, "user:1", "a", "b", "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !found[0] || !found[1] || found[2] {
+		t.Errorf("MightContain(a, b, c) = %v, want [true true false]", found)
+	}
+}
+
+func TestSortedSet(t *testing.T) {
+	cache, _ := newTestCacheManager(t)
+	ctx := context.Background()
+
+	for member, score := range map[string]float64{"a": 3, "b": 1, "c": 2} {
+		if err := cache.AddScore(ctx, "popularity", member, score); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := cache.IncrementScore(ctx, "popularity", "b", 5); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := cache.TrimMembers(ctx, "popularity", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	top, err := cache.TopMembers(ctx, "popularity", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if removed != 1 || len(top) != 2 || top[0].Member != "b" || top[1].Member != "a" {
+		t.Errorf("TrimMembers removed %d, TopMembers = %+v, want b then a", removed, top)
+	}
+
+	rank, found, err := cache.MemberRank(ctx, "popularity", "c")
+	if err != nil || found {
+		t.Errorf("MemberRank(c) = %d, %v, %v, want trimmed", rank, found, err)
+	}
+}
+
+func TestGetOrLockWaitsForTheLoader(t *testing.T) {
+	cache, _ := newTestCacheManager(t)
+	ctx := context.Background()
+
+	value, mutex, err := cache.GetOrLockCtx(ctx, "user:1")
+	if err != nil || value != nil || mutex == nil {
+		t.Fatalf("GetOrLock = %v, %v, %v, want the lock", value, mutex, err)
+	}
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = cache.StoreCtx(ctx, mutex, NewItem("user:1", "loaded"))
+	}()
+
+	value, mutex, err = cache.GetOrLockCtx(ctx, "user:1")
+	if err != nil || mutex != nil {
+		t.Fatalf("GetOrLock = %v, %v, want the loaded value", mutex, err)
+	}
+
+	if value, _ := redigo.String(value, nil); value != "loaded" {
+		t.Errorf("GetOrLock = %s, want the value of the loader", value)
+	}
+}
+
+func TestGetPurgeJobFailsOrphanedJob(t *testing.T) {
+	cache, server := newTestCacheManager(t)
+	ctx := context.Background()
+
+	// a job started by a replica which stopped before finishing it
+	heartbeatAt := time.Now().Add(-2 * purgeJobStaleAfter).UnixMilli()
+	server.HSet(cache.purgeJobKey("orphan"),
+		"pattern", "user:*",
+		"status", string(PurgeJobRunning),
+		"started_at", strconv.FormatInt(heartbeatAt, 10),
+		"owner", "stopped-replica",
+		"heartbeat_at", strconv.FormatInt(heartbeatAt, 10),
+	)
+
+	job, err := cache.GetPurgeJob(ctx, "orphan")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if job.Status != PurgeJobFailed || job.FinishedAt == nil {
+		t.Errorf("orphaned job = %+v, want failed", job)
+	}
+
+	if err := cache.CancelPurgeJob(ctx, "orphan"); err != ErrPurgeJobNotRunning {
+		t.Errorf("CancelPurgeJob of an orphaned job returned %v, want %v", err, ErrPurgeJobNotRunning)
+	}
+}
+
+func TestFailOrphanedPurgeJobs(t *testing.T) {
+	cache, server := newTestCacheManager(t)
+	ctx := context.Background()
+
+	staleAt := strconv.FormatInt(time.Now().Add(-2*purgeJobStaleAfter).UnixMilli(), 10)
+	aliveAt := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	for id, heartbeatAt := range map[string]string{"orphan": staleAt, "alive": aliveAt} {
+		server.HSet(cache.purgeJobKey(id),
+			"pattern", "user:*",
+			"status", string(PurgeJobRunning),
+			"started_at", heartbeatAt,
+			"owner", "replica",
+			"heartbeat_at", heartbeatAt,
+		)
+	}
+	// the job expired before the replica removed it from the running jobs
+	server.SAdd(cache.runningPurgeJobsKey(), "orphan", "alive", "expired")
+
+	failed, err := cache.FailOrphanedPurgeJobs(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if failed != 1 {
+		t.Errorf("FailOrphanedPurgeJobs = %d, want 1", failed)
+	}
+
+	if status := server.HGet(cache.purgeJobKey("orphan"), "status"); status != string(PurgeJobFailed) {
+		t.Errorf("orphaned job status = %s, want %s", status, PurgeJobFailed)
+	}
+
+	if status := server.HGet(cache.purgeJobKey("alive"), "status"); status != string(PurgeJobRunning) {
+		t.Errorf("alive job status = %s, want %s", status, PurgeJobRunning)
+	}
+
+	members, _ := server.Members(cache.runningPurgeJobsKey())
+	if len(members) != 1 || members[0] != "alive" {
+		t.Errorf("running jobs = %v, want [alive]", members)
+	}
+}
+
+func TestNamespaceVersionsArePerCacheManager(t *testing.T) {
+	cache, _ := newTestCacheManager(t)
+	other, _ := newTestCacheManager(t)
+	ctx := context.Background()
+
+	before := GetUserCacheKeyByID(other, 1)
+
+	if _, err := cache.BumpNamespaceVersion(ctx, NamespaceUser); err != nil {
+		t.Fatal(err)
+	}
+
+	if key := GetUserCacheKeyByID(cache, 1); key != before+":v1" {
+		t.Errorf("key after the bump = %s, want %s:v1", key, before)
+	}
+
+	// the other manager uses another redis, its keys did not move
+	if key := GetUserCacheKeyByID(other, 1); key != before {
+		t.Errorf("key of the other cache manager = %s, want %s", key, before)
+	}
+}
+
+func TestInvalidationsAreReplayedWhenRedisRecovers(t *testing.T) {
+	cache, server := newTestCacheManager(t)
+	ctx := context.Background()
+
+	for _, item := range []Item{newTestItem("user:1", "1"), newTestItem("user:2", "2", WithTags("team:a"))} {
+		if err := cache.StoreWithoutBlockingCtx(ctx, item); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cache.breaker.open.Store(true)
+
+	if err := cache.DeleteByKeysCtx(ctx, []string{"user:1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cache.InvalidateTagsCtx(ctx, "team:a"); err != nil {
+		t.Fatal(err)
+	}
+
+	if !server.Exists("user:1") || !server.Exists("user:2") {
+		t.Fatalf("keys deleted while the breaker is open: %v", server.Keys())
+	}
+
+	cache.breaker.probeInterval = time.Millisecond
+	cache.breaker.probeUntilRecovered()
+
+	if server.Exists("user:1") || server.Exists("user:2") {
+		t.Errorf("keys left after redis recovered: %v", server.Keys())
+	}
+}
+
+func TestStaleMetaIsNotStoredForRefusedVersion(t *testing.T) {
+	cache, server := newTestCacheManager(t)
+	ctx := context.Background()
+
+	now := time.Now()
+	load := func(user testUser) GetterCtxFn {
+		return func(ctx context.Context) (any, error) { return user, nil }
+	}
+
+	if _, err := cache.loadAndStore(ctx, nil, "user:1", load(testUser{ID: 1, UpdatedAt: now}), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// an older read of the row with a soft TTL loses the version check
+	older := load(testUser{ID: 1, UpdatedAt: now.Add(-time.Second)})
+	if _, err := cache.loadAndStore(ctx, nil, "user:1", older, []func(Item){WithSoftTTL(time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+
+	if server.Exists(staleMetaKey("user:1")) {
+		t.Errorf("stale meta stored for the refused version")
+	}
+}
+
+func TestTypedGetOrLoadDecodesWithTheStoredCodec(t *testing.T) {
+	cache, _ := newTestCacheManager(t)
+	cache.SetCodec(NewGzipCodec(NewMsgpackCodec()))
+	ctx := context.Background()
+
+	want := testUser{ID: 1, Name: "alice", UpdatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+	loads := 0
+	loader := func(ctx context.Context) (*testUser, error) {
+		loads++
+		user := want
+		return &user, nil
+	}
+
+	typed := NewTyped[testUser](cache)
+	for i := 0; i < 2; i++ {
+		user, err := typed.GetOrLoad(ctx, "user:1", loader)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if user == nil || user.ID != want.ID || user.Name != want.Name || !user.UpdatedAt.Equal(want.UpdatedAt) {
+			t.Errorf("GetOrLoad = %+v, want %+v", user, want)
+		}
+	}
+
+	if loads != 1 {
+		t.Errorf("loader called %d times, want 1", loads)
+	}
+}
@@ -0,0 +1,206 @@
+package cacher
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"reflect"
+
+	"github.com/mazharul-islam/utils"
+	"github.com/ugorji/go/codec"
+)
+
+// Format identifies the codec a cached payload was encoded with, it is stored in the payload header.
+type Format byte
+
+const (
+	FormatJSON    Format = 1
+	FormatMsgpack Format = 2
+
+	// formatGzip is set on the format of gzip compressed payloads
+	formatGzip Format = 0x80
+
+	// codecMagic starts every payload carrying a format header. 0xC1 is neither valid UTF-8 nor used by msgpack,
+	// so payloads written before codecs existed are never mistaken for one.
+	codecMagic byte = 0xC1
+)
+
+type (
+	// Codec is used to serialize the values stored by the cache manager.
+	Codec interface {
+		Format() Format
+		Marshal(v any) ([]byte, error)
+		Unmarshal(data []byte, v any) error
+	}
+
+	jsonCodec struct{}
+
+	msgpackCodec struct{}
+
+	gzipCodec struct {
+		codec Codec
+	}
+)
+
+var msgpackHandle = &codec.MsgpackHandle{WriteExt: true}
+
+func init() {
+	// decode to the same shapes as encoding/json when the target is an interface
+	msgpackHandle.RawToString = true
+	msgpackHandle.MapType = reflect.TypeOf(map[string]any(nil))
+}
+
+// NewJSONCodec creates a codec using encoding/json.
+func NewJSONCodec() Codec {
+	return jsonCodec{}
+}
+
+// NewMsgpackCodec creates a codec using msgpack, struct fields keep their json tag names.
+func NewMsgpackCodec() Codec {
+	return msgpackCodec{}
+}
+
+// NewGzipCodec creates a codec compressing the output of the given codec with gzip.
+func NewGzipCodec(c Codec) Codec {
+	return gzipCodec{codec: c}
+}
+
+// Format :nodoc:
+func (jsonCodec) Format() Format {
+	return FormatJSON
+}
+
+// Marshal :nodoc:
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal :nodoc:
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// Format :nodoc:
+func (msgpackCodec) Format() Format {
+	return FormatMsgpack
+}
+
+// Marshal :nodoc:
+func (msgpackCodec) Marshal(v any) (data []byte, err error) {
+	err = codec.NewEncoderBytes(&data, msgpackHandle).Encode(v)
+	return
+}
+
+// Unmarshal :nodoc:
+func (msgpackCodec) Unmarshal(data []byte, v any) error {
+	return codec.NewDecoderBytes(data, msgpackHandle).Decode(v)
+}
+
+// Format :nodoc:
+func (gz gzipCodec) Format() Format {
+	return gz.codec.Format() | formatGzip
+}
+
+// Marshal :nodoc:
+func (gz gzipCodec) Marshal(v any) ([]byte, error) {
+	data, err := gz.codec.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Unmarshal :nodoc:
+func (gz gzipCodec) Unmarshal(data []byte, v any) error {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer utils.WrapCloser(reader.Close)
+
+	data, err = io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+
+	return gz.codec.Unmarshal(data, v)
+}
+
+// Marshal encodes the value with the codec and prepends the format header.
+func Marshal(c Codec, v any) ([]byte, error) {
+	payload, err := c.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte{codecMagic, byte(c.Format())}, payload...), nil
+}
+
+// Unmarshal decodes a cached payload with the codec named in its format header,
+// so values written with another codec are still readable. Payloads without header are JSON.
+// The envelope of versioned payloads is skipped.
+func Unmarshal(data []byte, v any) error {
+	_, data, _ = parseEnvelope(data)
+	if len(data) < 2 || data[0] != codecMagic {
+		return json.Unmarshal(data, v)
+	}
+
+	c, err := codecByFormat(Format(data[1]))
+	if err != nil {
+		return err
+	}
+
+	return c.Unmarshal(data[2:], v)
+}
+
+// decodeJSON returns the JSON document of a cached payload, the payloads written with another codec are converted.
+// The format header and the envelope of versioned payloads are removed.
+func decodeJSON(data []byte) ([]byte, error) {
+	_, data, _ = parseEnvelope(data)
+	if len(data) < 2 || data[0] != codecMagic {
+		return data, nil
+	}
+
+	if Format(data[1]) == FormatJSON {
+		return data[2:], nil
+	}
+
+	var value any
+	if err := Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(value)
+}
+
+func codecByFormat(format Format) (Codec, error) {
+	if format&formatGzip != 0 {
+		c, err := codecByFormat(format &^ formatGzip)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewGzipCodec(c), nil
+	}
+
+	switch format {
+	case FormatJSON:
+		return NewJSONCodec(), nil
+	case FormatMsgpack:
+		return NewMsgpackCodec(), nil
+	default:
+		return nil, ErrUnknownCodecFormat
+	}
+}
@@ -1,21 +1,27 @@
 package cacher
 
 import (
+	"context"
+	"fmt"
 	redigo "github.com/gomodule/redigo/redis"
-	"github.com/mazharul-islam/config"
 	"github.com/mazharul-islam/utils"
 	log "github.com/sirupsen/logrus"
-	"regexp"
+	"strconv"
+	"time"
 )
 
 type MultiResponse struct {
 	IDs   []int64 `json:"ids"`
 	Count int64   `json:"count"`
+
+	// Before and After are the cursors of the neighbour pages of a cursor paginated list
+	Before *string `json:"before,omitempty"`
+	After  *string `json:"after,omitempty"`
 }
 
 // NewMultiResponseFromByte converts interface to multi response entity.
 func NewMultiResponseFromByte(bt []byte) (mr *MultiResponse, err error) {
-	if err := utils.JSONUnmarshal(bt, &mr); err != nil {
+	if err := Unmarshal(bt, &mr); err != nil {
 		log.WithField("bt", string(bt)).Error(err)
 		return nil, err
 	}
@@ -38,7 +44,7 @@ func (mr *MultiResponse) ToByte() []byte {
 	return utils.ToByte(mr)
 }
 
-func get(client redigo.Conn, key string) (value any, err error) {
+func get(ctx context.Context, client redigo.Conn, key string) (value any, err error) {
 	defer utils.WrapCloser(client.Close)
 
 	if err := client.Send("MULTI"); err != nil {
@@ -53,7 +59,7 @@ func get(client redigo.Conn, key string) (value any, err error) {
 		return nil, err
 	}
 
-	res, err := redigo.Values(client.Do("EXEC"))
+	res, err := redigo.Values(redigo.DoContext(client, ctx, "EXEC"))
 	if err != nil {
 		return nil, err
 	}
@@ -66,15 +72,7 @@ func get(client redigo.Conn, key string) (value any, err error) {
 	return res[1], nil
 }
 
-func createCacheKey(value string) string {
-	prefix := utils.WriteStringTemplate("%s_%s_", defaultPrefixCacheKey, config.EnvironmentMode())
-	re := regexp.MustCompile("=|&")
-	cacheKey := prefix + re.ReplaceAllString(value, "_")
-
-	return cacheKey
-}
-
-func getHashMember(client redigo.Conn, identifier, key string) (value any, err error) {
+func getHashMember(ctx context.Context, client redigo.Conn, identifier, key string) (value any, err error) {
 	defer func() {
 		_ = client.Close()
 	}()
@@ -91,7 +89,7 @@ func getHashMember(client redigo.Conn, identifier, key string) (value any, err e
 		return nil, err
 	}
 
-	res, err := redigo.Values(client.Do("EXEC"))
+	res, err := redigo.Values(redigo.DoContext(client, ctx, "EXEC"))
 	if err != nil {
 		return nil, err
 	}
@@ -103,3 +101,171 @@ func getHashMember(client redigo.Conn, identifier, key string) (value any, err e
 
 	return res[1], nil
 }
+
+// matchPattern reports whether the key matches the glob style pattern with the rules of redis KEYS and SCAN MATCH,
+// * and ? match any byte including /, [...] supports ^ negation and a-z ranges, \ escapes the next byte.
+func matchPattern(pattern, key string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(key); i++ {
+				if matchPattern(pattern[1:], key[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(key) == 0 {
+				return false
+			}
+			key = key[1:]
+		case '[':
+			if len(key) == 0 {
+				return false
+			}
+
+			var matched bool
+			matched, pattern = matchClass(pattern[1:], key[0])
+			if !matched {
+				return false
+			}
+			key = key[1:]
+			// matchClass leaves the pattern on the closing bracket
+			if len(pattern) == 0 {
+				return len(key) == 0
+			}
+		case '\\':
+			if len(pattern) >= 2 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(key) == 0 || pattern[0] != key[0] {
+				return false
+			}
+			key = key[1:]
+		}
+
+		pattern = pattern[1:]
+	}
+
+	return len(key) == 0
+}
+
+// matchClass matches the byte against the [...] class at the start of the pattern, which is given without its
+// opening bracket. It returns the pattern from the closing bracket, an unclosed class runs to the end of the pattern.
+func matchClass(pattern string, b byte) (bool, string) {
+	not := len(pattern) > 0 && pattern[0] == '^'
+	if not {
+		pattern = pattern[1:]
+	}
+
+	matched := false
+	for len(pattern) > 0 && pattern[0] != ']' {
+		switch {
+		case pattern[0] == '\\' && len(pattern) >= 2:
+			pattern = pattern[1:]
+			if pattern[0] == b {
+				matched = true
+			}
+		case len(pattern) >= 3 && pattern[1] == '-':
+			start, end := pattern[0], pattern[2]
+			if start > end {
+				start, end = end, start
+			}
+			if b >= start && b <= end {
+				matched = true
+			}
+			pattern = pattern[2:]
+		default:
+			if pattern[0] == b {
+				matched = true
+			}
+		}
+
+		pattern = pattern[1:]
+	}
+
+	if not {
+		matched = !matched
+	}
+
+	return matched, pattern
+}
+
+// receiveReplies flushes the commands sent on the connection and reads their count replies, returning the first error.
+// Unlike a transaction, a pipeline may write keys of several cluster slots.
+func receiveReplies(ctx context.Context, client redigo.Conn, count int) ([]any, error) {
+	if err := client.Flush(); err != nil {
+		return nil, err
+	}
+
+	var firstErr error
+	replies := make([]any, 0, count)
+	for i := 0; i < count; i++ {
+		reply, err := redigo.ReceiveContext(client, ctx)
+		if err != nil {
+			// a connection error leaves the remaining replies unreadable
+			if _, isRedisErr := err.(redigo.Error); !isRedisErr {
+				return nil, err
+			}
+
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+
+		replies = append(replies, reply)
+	}
+
+	return replies, firstErr
+}
+
+// toBytes converts a value the way redis stores it.
+func toBytes(value any) []byte {
+	switch v := value.(type) {
+	case []byte:
+		return v
+	case string:
+		return []byte(v)
+	case nil:
+		return []byte{}
+	case bool:
+		if v {
+			return []byte("1")
+		}
+		return []byte("0")
+	case float64:
+		return []byte(strconv.FormatFloat(v, 'g', -1, 64))
+	default:
+		return []byte(fmt.Sprint(v))
+	}
+}
+
+func itemKeys(items []Item) []string {
+	keys := make([]string, 0, len(items))
+	for _, item := range items {
+		keys = append(keys, item.GetKey())
+	}
+
+	return keys
+}
+
+// sleepWithContext pauses for the duration, returning the context error when it is done earlier.
+func sleepWithContext(ctx context.Context, duration time.Duration) error {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
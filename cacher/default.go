@@ -9,4 +9,22 @@ const (
 	defaultLockTries      = 1
 	defaultWaitTime       = 15 * time.Second
 	defaultPrefixCacheKey = "mazharul-islam"
+	tagDeleteBatchSize    = 500
+	purgeScanCount        = 1000
+	purgeBatchSize        = 500
+	purgeJobTTL           = 24 * time.Hour
+
+	// purgeJobHeartbeatInterval is how often a replica running a purge job reports it is alive, the jobs of a replica
+	// silent for purgeJobStaleAfter are failed, e.g. after the replica was restarted
+	purgeJobHeartbeatInterval = 5 * time.Second
+	purgeJobStaleAfter        = 6 * purgeJobHeartbeatInterval
+
+	// bloomFilterTTL keeps the filter of a day while it is read as the filter of the day before
+	bloomFilterTTL = 2 * 24 * time.Hour
+
+	defaultBreakerFailureThreshold = 5
+	defaultBreakerProbeInterval    = 1 * time.Second
+
+	// maxPendingInvalidations caps the keys and tags queued while redis is unavailable
+	maxPendingInvalidations = 10000
 )
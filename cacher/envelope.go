@@ -0,0 +1,154 @@
+package cacher
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"time"
+
+	redigo "github.com/gomodule/redigo/redis"
+	"github.com/mazharul-islam/utils"
+)
+
+const (
+	// envelopeMagic starts every versioned payload. Like codecMagic, 0xC0 never starts a JSON payload,
+	// so payloads written without envelope are never mistaken for one.
+	envelopeMagic byte = 0xC0
+
+	// envelopeHeaderMaxSize bounds the header read by the compare-and-set script and the debug tools,
+	// the magic byte followed by three decimal integers ending with a colon.
+	envelopeHeaderMaxSize = 64
+)
+
+// compareAndSetScript replaces the key unless it holds a newer version. Versions are compared as decimal strings,
+// since the numbers of the lua interpreter lose precision past 2^53.
+// KEYS[1]: key, ARGV[1]: version, ARGV[2]: enveloped value, ARGV[3]: ttl in seconds. Returns 0 when rejected
+var compareAndSetScript = redigo.NewScript(1, `
+local header = redis.call('GETRANGE', KEYS[1], 0, 63)
+local current = string.match(header, '^\192(%d+):')
+if current and (#current > #ARGV[1] or (#current == #ARGV[1] and current > ARGV[1])) then
+	return 0
+end
+
+redis.call('SET', KEYS[1], ARGV[2], 'EX', ARGV[3])
+return 1
+`)
+
+type (
+	// Versioned is implemented by the values which know their own version, e.g. from their updated at column.
+	// Values loaded by GetOrSet and GetOrSetMulti are stored with their version when they implement it.
+	Versioned interface {
+		CacheVersion() uint64
+	}
+
+	// EnvelopeMeta is the metadata stored in front of a versioned value.
+	EnvelopeMeta struct {
+		Version   uint64        `json:"version"`
+		CreatedAt time.Time     `json:"createdAt"`
+		SoftTTL   time.Duration `json:"softTTL"`
+		// Stale is true once the soft TTL has passed when the envelope was read
+		Stale bool `json:"stale"`
+	}
+)
+
+// GetEnvelope returns the envelope metadata of the key, nil when the value was stored without version.
+func (cache *cacheManager) GetEnvelope(ctx context.Context, key string) (meta *EnvelopeMeta, err error) {
+	if cache.skipCache() {
+		return nil, ErrKeyNotExist
+	}
+
+	ctx, span := startSpan(ctx, "GetEnvelope", key)
+	defer func() { endSpan(span, err) }()
+
+	client, err := cache.getConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer utils.WrapCloser(client.Close)
+
+	header, err := redigo.Bytes(redigo.DoContext(client, ctx, "GETRANGE", key, 0, envelopeHeaderMaxSize-1))
+	if err != nil {
+		return nil, err
+	}
+
+	// GETRANGE returns an empty string for missing keys
+	if len(header) == 0 {
+		return nil, ErrKeyNotExist
+	}
+
+	meta, _, _ = parseEnvelope(header)
+	return meta, nil
+}
+
+// compareAndSet stores a versioned item unless the key holds a newer version.
+func (cache *cacheManager) compareAndSet(ctx context.Context, client redigo.Conn, item Item, version uint64) error {
+	stored, err := redigo.Bool(compareAndSetScript.DoContext(ctx, client, item.GetKey(),
+		strconv.FormatUint(version, 10), envelopeValue(item, version), cache.decideCacheTTL(item)))
+	if err != nil {
+		return err
+	}
+
+	if !stored {
+		return ErrStaleVersion
+	}
+
+	return nil
+}
+
+// WithVersion stores the item with a compare-and-set, the write is rejected with ErrStaleVersion when the key
+// holds a newer version. Values written without version are always replaced.
+func WithVersion(version uint64) func(Item) {
+	return func(i Item) {
+		i.SetVersion(version)
+	}
+}
+
+// applyVersion sets the version of the item from its loaded value, when the value implements Versioned.
+func applyVersion(item Item, value any) {
+	if versioned, ok := value.(Versioned); ok {
+		item.SetVersion(versioned.CacheVersion())
+	}
+}
+
+// envelopeValue prepends the envelope header to the value of the item,
+// e.g. \xC0<version>:<created at unix ms>:<soft ttl ms>:<payload>.
+func envelopeValue(item Item, version uint64) []byte {
+	header := utils.WriteStringTemplate("%d:%d:%d:", version, time.Now().UnixMilli(), item.GetSoftTTL().Milliseconds())
+
+	value := append([]byte{envelopeMagic}, header...)
+	return append(value, toBytes(item.GetValue())...)
+}
+
+// parseEnvelope splits a versioned payload into its metadata and value. ok is false when the payload has no envelope,
+// the value is truncated when only the header was read.
+func parseEnvelope(data []byte) (meta *EnvelopeMeta, value []byte, ok bool) {
+	if len(data) == 0 || data[0] != envelopeMagic {
+		return nil, data, false
+	}
+
+	rest := data[1:]
+	var fields [3]uint64
+	for i := range fields {
+		end := bytes.IndexByte(rest, ':')
+		if end <= 0 {
+			return nil, data, false
+		}
+
+		field, err := strconv.ParseUint(string(rest[:end]), 10, 64)
+		if err != nil {
+			return nil, data, false
+		}
+
+		fields[i] = field
+		rest = rest[end+1:]
+	}
+
+	meta = &EnvelopeMeta{
+		Version:   fields[0],
+		CreatedAt: time.UnixMilli(int64(fields[1])),
+		SoftTTL:   time.Duration(fields[2]) * time.Millisecond,
+	}
+	meta.Stale = meta.SoftTTL > 0 && time.Since(meta.CreatedAt) > meta.SoftTTL
+
+	return meta, rest, true
+}
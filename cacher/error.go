@@ -7,4 +7,12 @@ var (
 	ErrKeyNotExist             = errors.New("key not exist")
 	ErrInvalidCacheValue       = errors.New("invalid cache value")
 	ErrFailedCastMultiResponse = errors.New("failed to cast cache multi response")
+	ErrUnknownCodecFormat      = errors.New("unknown cache codec format")
+	ErrLockLost                = errors.New("lock lost while loading")
+	ErrPurgeJobNotFound        = errors.New("purge job not found")
+	ErrPurgeJobNotRunning      = errors.New("purge job is not running")
+	ErrUnknownNamespace        = errors.New("unknown cache namespace")
+	ErrCacheUnavailable        = errors.New("cache unavailable")
+	ErrStaleVersion            = errors.New("cached version is newer")
+	ErrInvalidBloomErrorRate   = errors.New("bloom filter error rate must be between 0 and 1")
 )
@@ -3,14 +3,18 @@ package cacher
 import (
 	"context"
 	"github.com/go-redsync/redsync/v4"
-	"github.com/mazharul-islam/utils"
 	"github.com/sirupsen/logrus"
 )
 
 func FindFromCacheByKey[T any](cache CacheManager, key string) (item T, mutex *redsync.Mutex, err error) {
+	return FindFromCacheByKeyCtx[T](context.Background(), cache, key)
+}
+
+// FindFromCacheByKeyCtx is the context aware variant of FindFromCacheByKey.
+func FindFromCacheByKeyCtx[T any](ctx context.Context, cache CacheManager, key string) (item T, mutex *redsync.Mutex, err error) {
 	var cachedData any
 
-	cachedData, mutex, err = cache.GetOrLock(key)
+	cachedData, mutex, err = cache.GetOrLockCtx(ctx, key)
 	if err != nil || cachedData == nil {
 		return
 	}
@@ -20,7 +24,7 @@ func FindFromCacheByKey[T any](cache CacheManager, key string) (item T, mutex *r
 		return
 	}
 
-	if err = utils.JSONUnmarshal(cachedDataByte, &item); err != nil {
+	if err = Unmarshal(cachedDataByte, &item); err != nil {
 		return
 	}
 
@@ -34,6 +38,16 @@ func FindFromCacheByKeyWithoutMutex(cache CacheManager, cacheKey string) (string
 	}
 
 	bt, _ := cachedData.([]byte)
+	if bt == nil {
+		return "", nil
+	}
+
+	// keep returning the JSON document, whichever codec the value is stored with
+	bt, err = decodeJSON(bt)
+	if err != nil {
+		return "", err
+	}
+
 	return string(bt), nil
 }
 
@@ -45,7 +59,7 @@ func SafeUnlock(mutex *redsync.Mutex) {
 }
 
 func StoreNil(ctx context.Context, cache CacheManager, cacheKey string) {
-	if err := cache.StoreNil(cacheKey); err != nil {
+	if err := cache.StoreNilCtx(ctx, cacheKey); err != nil {
 		logrus.WithContext(ctx).WithField("cacheKey", cacheKey).Error(err)
 	}
 }
@@ -67,14 +81,19 @@ func FindHashMemberFromBucketAndCacheKey[T any](cache CacheManager, bucket, key
 		return
 	}
 
-	if err = utils.JSONUnmarshal(bt, &item); err != nil {
+	if err = Unmarshal(bt, &item); err != nil {
 		return
 	}
 	return
 }
 
 func FindMultiResponseFromCacheByKey(cache CacheManager, bucket, key string) (multiResponse *MultiResponse, mu *redsync.Mutex, err error) {
-	reply, mu, err := cache.GetHashMemberOrLock(bucket, key)
+	return FindMultiResponseFromCacheByKeyCtx(context.Background(), cache, bucket, key)
+}
+
+// FindMultiResponseFromCacheByKeyCtx is the context aware variant of FindMultiResponseFromCacheByKey.
+func FindMultiResponseFromCacheByKeyCtx(ctx context.Context, cache CacheManager, bucket, key string) (multiResponse *MultiResponse, mu *redsync.Mutex, err error) {
+	reply, mu, err := cache.GetHashMemberOrLockCtx(ctx, bucket, key)
 	if err != nil {
 		return
 	}
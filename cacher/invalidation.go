@@ -0,0 +1,170 @@
+package cacher
+
+import (
+	"context"
+	"time"
+
+	redigo "github.com/gomodule/redigo/redis"
+	"github.com/jpillora/backoff"
+	"github.com/mazharul-islam/utils"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// invalidationPingInterval is how often the subscriber pings redis, a connection answering neither messages
+	// nor pings for invalidationReadTimeout is considered dead and replaced
+	invalidationPingInterval = 10 * time.Second
+	invalidationReadTimeout  = 3 * invalidationPingInterval
+)
+
+// invalidationMessage is published to other replicas so they drop their local cache entries
+// and follow the bumped namespace versions
+type invalidationMessage struct {
+	Origin    string   `json:"origin"`
+	Keys      []string `json:"keys,omitempty"`
+	Pattern   string   `json:"pattern,omitempty"`
+	Namespace string   `json:"namespace,omitempty"`
+	Version   int64    `json:"version,omitempty"`
+}
+
+// invalidateLocal drops the keys from the local cache and tells the other replicas to do the same.
+func (cache *cacheManager) invalidateLocal(keys ...string) {
+	if cache.localCache == nil || len(keys) == 0 {
+		return
+	}
+
+	cache.localCache.delete(keys...)
+	cache.publishInvalidation(invalidationMessage{Keys: keys})
+}
+
+// invalidateLocalPattern drops the keys matching the pattern from the local cache and tells the other replicas to do the same.
+func (cache *cacheManager) invalidateLocalPattern(pattern string) {
+	if cache.localCache == nil {
+		return
+	}
+
+	cache.localCache.purge(pattern)
+	cache.publishInvalidation(invalidationMessage{Pattern: pattern})
+}
+
+func (cache *cacheManager) publishInvalidation(message invalidationMessage) {
+	message.Origin = cache.instanceID
+
+	client := cache.conn()
+	defer utils.WrapCloser(client.Close)
+
+	if _, err := client.Do("PUBLISH", cache.invalidationChannel(), utils.Dump(message)); err != nil {
+		logrus.WithField("message", utils.Dump(message)).Error(err)
+	}
+}
+
+// ListenInvalidation subscribes to invalidation messages published by the other replicas
+// and applies them to the local cache and the namespace versions. It blocks and reconnects until the context is done.
+func (cache *cacheManager) ListenInvalidation(ctx context.Context) error {
+	if cache.disableCaching {
+		return nil
+	}
+
+	b := &backoff.Backoff{
+		Min:    100 * time.Millisecond,
+		Max:    10 * time.Second,
+		Jitter: true,
+	}
+
+	for {
+		err := cache.receiveInvalidation(ctx, b)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		logrus.WithField("channel", cache.invalidationChannel()).Error(err)
+
+		// anything may have changed while disconnected
+		cache.localCache.purge("*")
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(b.Duration()):
+		}
+	}
+}
+
+func (cache *cacheManager) receiveInvalidation(ctx context.Context, b *backoff.Backoff) error {
+	conn := redigo.PubSubConn{Conn: cache.subscriberConnPool.Get()}
+	defer utils.WrapCloser(conn.Close)
+
+	if err := conn.Subscribe(cache.invalidationChannel()); err != nil {
+		return err
+	}
+
+	received := make(chan error, 1)
+	go func() {
+		received <- cache.handleInvalidation(ctx, conn, b)
+	}()
+
+	ticker := time.NewTicker(invalidationPingInterval)
+	defer ticker.Stop()
+
+	// the connection is closed once the receiving goroutine is done, it must not read a closing connection
+	for {
+		select {
+		case err := <-received:
+			return err
+		case <-ctx.Done():
+			// the receiving goroutine stops when redis confirms the unsubscription
+			_ = conn.Unsubscribe()
+			return <-received
+		case <-ticker.C:
+			// a dead connection misses the pong and times out the receiving goroutine
+			if err := conn.Ping(""); err != nil {
+				<-received
+				return err
+			}
+		}
+	}
+}
+
+// handleInvalidation applies the invalidation messages until the connection fails or is unsubscribed.
+func (cache *cacheManager) handleInvalidation(ctx context.Context, conn redigo.PubSubConn, b *backoff.Backoff) error {
+	for {
+		switch reply := conn.ReceiveWithTimeout(invalidationReadTimeout).(type) {
+		case redigo.Subscription:
+			if reply.Count == 0 {
+				return nil
+			}
+
+			b.Reset()
+
+			// bumps published while disconnected were missed
+			if err := cache.LoadNamespaceVersions(ctx); err != nil {
+				logrus.WithField("channel", cache.invalidationChannel()).Error(err)
+			}
+		case redigo.Message:
+			var message invalidationMessage
+			if err := utils.JSONUnmarshal(reply.Data, &message); err != nil {
+				logrus.WithField("data", string(reply.Data)).Error(err)
+				continue
+			}
+
+			if message.Origin == cache.instanceID {
+				continue
+			}
+
+			cache.localCache.delete(message.Keys...)
+			if message.Pattern != "" {
+				cache.localCache.purge(message.Pattern)
+			}
+
+			if message.Namespace != "" {
+				cache.keyspace.setVersions(map[string]int64{message.Namespace: message.Version})
+			}
+		case error:
+			return reply
+		}
+	}
+}
+
+func (cache *cacheManager) invalidationChannel() string {
+	return utils.WriteStringTemplate("%s_%s_:invalidation", cache.prefixCacheKey, cache.environment)
+}
@@ -10,12 +10,24 @@ type (
 		GetKey() string
 		GetValue() any
 		SetTTL(ttl time.Duration)
+		GetTags() []string
+		AddTags(tags ...string)
+		GetSoftTTL() time.Duration
+		SetSoftTTL(ttl time.Duration)
+		GetEarlyExpiryBeta() float64
+		SetEarlyExpiryBeta(beta float64)
+		GetVersion() (version uint64, ok bool)
+		SetVersion(version uint64)
 	}
 
 	item struct {
-		key   string
-		value any
-		ttl   time.Duration
+		key             string
+		value           any
+		ttl             time.Duration
+		tags            []string
+		softTTL         time.Duration
+		earlyExpiryBeta float64
+		version         *uint64
 	}
 )
 
@@ -26,6 +38,29 @@ func WithTTL(ttl time.Duration) func(Item) {
 	}
 }
 
+// WithTags attaches tags to an item in the GetOrSet function, so it can be removed with InvalidateTags.
+func WithTags(tags ...string) func(Item) {
+	return func(i Item) {
+		i.AddTags(tags...)
+	}
+}
+
+// WithSoftTTL enables stale-while-revalidate in the GetOrSet function. Once the soft TTL has passed, the stale
+// value is still returned immediately while a single background refresh repopulates the key.
+func WithSoftTTL(softTTL time.Duration) func(Item) {
+	return func(i Item) {
+		i.SetSoftTTL(softTTL)
+	}
+}
+
+// WithEarlyExpiry makes a soft TTL item refresh probabilistically before its soft TTL, to avoid synchronized
+// refreshes of keys written at the same time. Beta 1 is a good default, higher values refresh earlier.
+func WithEarlyExpiry(beta float64) func(Item) {
+	return func(i Item) {
+		i.SetEarlyExpiryBeta(beta)
+	}
+}
+
 // NewItem creates a new cache item with the given key and value.
 func NewItem(key string, value any) Item {
 	return &item{
@@ -62,3 +97,47 @@ func (item *item) GetKey() string {
 func (item *item) GetValue() any {
 	return item.value
 }
+
+// GetTags returns the tags attached to the item.
+func (item *item) GetTags() []string {
+	return item.tags
+}
+
+// AddTags attaches tags to the item.
+func (item *item) AddTags(tags ...string) {
+	item.tags = append(item.tags, tags...)
+}
+
+// GetSoftTTL returns the soft time-to-live (TTL) of the item.
+func (item *item) GetSoftTTL() time.Duration {
+	return item.softTTL
+}
+
+// SetSoftTTL sets the soft time-to-live (TTL) for the item.
+func (item *item) SetSoftTTL(ttl time.Duration) {
+	item.softTTL = ttl
+}
+
+// GetEarlyExpiryBeta returns the probabilistic early expiry factor of the item.
+func (item *item) GetEarlyExpiryBeta() float64 {
+	return item.earlyExpiryBeta
+}
+
+// SetEarlyExpiryBeta sets the probabilistic early expiry factor for the item.
+func (item *item) SetEarlyExpiryBeta(beta float64) {
+	item.earlyExpiryBeta = beta
+}
+
+// GetVersion returns the version of the item, ok is false when the item is not versioned.
+func (item *item) GetVersion() (uint64, bool) {
+	if item.version == nil {
+		return 0, false
+	}
+
+	return *item.version, true
+}
+
+// SetVersion sets the version of the item.
+func (item *item) SetVersion(version uint64) {
+	item.version = &version
+}
@@ -0,0 +1,124 @@
+package cacher
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+type (
+	// localCache is a bounded in-process LRU used as the first cache tier in front of redis.
+	// All methods are safe to call on a nil receiver, which behaves as a disabled cache.
+	localCache struct {
+		mu      sync.Mutex
+		ttl     time.Duration
+		maxSize int
+		items   map[string]*list.Element
+		order   *list.List
+	}
+
+	localCacheEntry struct {
+		key       string
+		value     any
+		expiredAt time.Time
+	}
+)
+
+func newLocalCache(maxSize int, ttl time.Duration) *localCache {
+	return &localCache{
+		ttl:     ttl,
+		maxSize: maxSize,
+		items:   make(map[string]*list.Element, maxSize),
+		order:   list.New(),
+	}
+}
+
+// get returns the value stored for the key when it has not expired yet.
+func (local *localCache) get(key string) (any, bool) {
+	if local == nil {
+		return nil, false
+	}
+
+	local.mu.Lock()
+	defer local.mu.Unlock()
+
+	element, ok := local.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := element.Value.(*localCacheEntry)
+	if time.Now().After(entry.expiredAt) {
+		local.removeElement(element)
+		return nil, false
+	}
+
+	local.order.MoveToFront(element)
+	return entry.value, true
+}
+
+// set stores the value and evicts the least recently used entry when the cache is full.
+func (local *localCache) set(key string, value any) {
+	if local == nil {
+		return
+	}
+
+	local.mu.Lock()
+	defer local.mu.Unlock()
+
+	expiredAt := time.Now().Add(local.ttl)
+	if element, ok := local.items[key]; ok {
+		entry := element.Value.(*localCacheEntry)
+		entry.value = value
+		entry.expiredAt = expiredAt
+		local.order.MoveToFront(element)
+		return
+	}
+
+	local.items[key] = local.order.PushFront(&localCacheEntry{
+		key:       key,
+		value:     value,
+		expiredAt: expiredAt,
+	})
+
+	for local.order.Len() > local.maxSize {
+		local.removeElement(local.order.Back())
+	}
+}
+
+// delete removes the entries of the given keys.
+func (local *localCache) delete(keys ...string) {
+	if local == nil {
+		return
+	}
+
+	local.mu.Lock()
+	defer local.mu.Unlock()
+
+	for _, key := range keys {
+		if element, ok := local.items[key]; ok {
+			local.removeElement(element)
+		}
+	}
+}
+
+// purge removes the entries whose key matches the redis glob style pattern.
+func (local *localCache) purge(pattern string) {
+	if local == nil {
+		return
+	}
+
+	local.mu.Lock()
+	defer local.mu.Unlock()
+
+	for key, element := range local.items {
+		if matchPattern(pattern, key) {
+			local.removeElement(element)
+		}
+	}
+}
+
+func (local *localCache) removeElement(element *list.Element) {
+	local.order.Remove(element)
+	delete(local.items, element.Value.(*localCacheEntry).key)
+}
@@ -0,0 +1,156 @@
+package cacher
+
+import (
+	"context"
+	"time"
+
+	redigo "github.com/gomodule/redigo/redis"
+	"github.com/mazharul-islam/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// BatchGetterFn loads the values of the given keys, keys missing from the result are cached as nil.
+type BatchGetterFn func(ctx context.Context, keys []string) (map[string]any, error)
+
+// GetMulti is used to retrieve several items stored in the cache with a single round trip.
+// Keys which are not cached are left out of the result.
+func (cache *cacheManager) GetMulti(keys []string) (map[string]any, error) {
+	return cache.GetMultiCtx(context.Background(), keys)
+}
+
+// GetMultiCtx is the context aware variant of GetMulti.
+func (cache *cacheManager) GetMultiCtx(ctx context.Context, keys []string) (cachedItems map[string]any, err error) {
+	cachedItems = make(map[string]any, len(keys))
+	if cache.skipCache() || len(keys) == 0 {
+		return
+	}
+
+	ctx, span := startSpan(ctx, "GetMulti", "")
+	defer func() { endSpan(span, err) }()
+
+	defer func() {
+		if err != nil {
+			return
+		}
+
+		for _, key := range utils.Unique(keys) {
+			cache.recordLookup(key, cachedItems[key])
+		}
+	}()
+
+	var redisKeys []string
+	for _, key := range utils.Unique(keys) {
+		if cachedItem, ok := cache.localCache.get(key); ok {
+			cachedItems[key] = cachedItem
+			continue
+		}
+
+		redisKeys = append(redisKeys, key)
+	}
+
+	if len(redisKeys) == 0 {
+		return
+	}
+
+	client, err := cache.getConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer utils.WrapCloser(client.Close)
+
+	values, err := redigo.Values(redigo.DoContext(client, ctx, "MGET", redigo.Args{}.AddFlat(redisKeys)...))
+	if err != nil {
+		return nil, err
+	}
+
+	for i, value := range values {
+		if value == nil {
+			continue
+		}
+
+		cachedItems[redisKeys[i]] = value
+		cache.localCache.set(redisKeys[i], value)
+	}
+
+	return
+}
+
+// GetOrSetMulti is used to retrieve several values from the cache, loading only the missing keys with the batch
+// getter function and storing them for future use. Values carry the codec format header, decode them with Unmarshal.
+// Nil values are left out of the result. Missing keys are loaded without locking.
+func (cache *cacheManager) GetOrSetMulti(keys []string, fn BatchGetterFn, opts ...func(Item)) (map[string][]byte, error) {
+	return cache.GetOrSetMultiCtx(context.Background(), keys, fn, opts...)
+}
+
+// GetOrSetMultiCtx is the context aware variant of GetOrSetMulti, the context is passed to the batch getter function.
+func (cache *cacheManager) GetOrSetMultiCtx(ctx context.Context, keys []string, fn BatchGetterFn, opts ...func(Item)) (res map[string][]byte, err error) {
+	ctx, span := startSpan(ctx, "GetOrSetMulti", "")
+	defer func() { endSpan(span, err) }()
+
+	return getOrSetMulti(ctx, cache, cache.nilTTL, keys, fn, opts)
+}
+
+// getOrSetMulti implements GetOrSetMulti on top of the batch read and write of a cache manager.
+func getOrSetMulti(ctx context.Context, cache CacheManager, nilTTL time.Duration, keys []string, fn BatchGetterFn, opts []func(Item)) (map[string][]byte, error) {
+	cachedItems, err := cache.GetMultiCtx(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string][]byte, len(keys))
+	var missingKeys []string
+	for _, key := range utils.Unique(keys) {
+		cachedItem, ok := cachedItems[key]
+		if !ok {
+			missingKeys = append(missingKeys, key)
+			continue
+		}
+
+		cachedValue, ok := cachedItem.([]byte)
+		if !ok {
+			return nil, ErrInvalidCacheValue
+		}
+
+		if string(cachedValue) != string(nilValue) {
+			res[key] = cachedValue
+		}
+	}
+
+	if len(missingKeys) == 0 {
+		return res, nil
+	}
+
+	loadedItems, err := fn(ctx, missingKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]Item, 0, len(missingKeys))
+	for _, key := range missingKeys {
+		loadedItem := loadedItems[key]
+		if loadedItem == nil {
+			items = append(items, NewItemWithCustomTTL(key, nilValue, nilTTL))
+			continue
+		}
+
+		cachedValue, err := cache.Marshal(loadedItem)
+		if err != nil {
+			return nil, err
+		}
+
+		cacheItem := NewItem(key, cachedValue)
+		applyVersion(cacheItem, loadedItem)
+		for _, o := range opts {
+			o(cacheItem)
+		}
+
+		items = append(items, cacheItem)
+		res[key] = cachedValue
+	}
+
+	if err := cache.StoreMultiWithoutBlockingCtx(ctx, items); err != nil {
+		logrus.WithField("cacheKeys", missingKeys).Error(err)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,140 @@
+package cacher
+
+import (
+	"context"
+	"regexp"
+	"sync"
+
+	redigo "github.com/gomodule/redigo/redis"
+	"github.com/mazharul-islam/utils"
+)
+
+const (
+	NamespaceUser        = "user"
+	NamespaceCustomer    = "customer"
+	NamespaceBloom       = "bloom"
+	NamespaceLeaderboard = "leaderboard"
+)
+
+// cacheKeyReplacer replaces the query string separators of a cache key value
+var cacheKeyReplacer = regexp.MustCompile("=|&")
+
+// namespaces lists the key families which can be versioned
+var namespaces = []string{NamespaceUser, NamespaceCustomer, NamespaceBloom, NamespaceLeaderboard}
+
+// cacheKeyspace holds the namespace versions of a cache manager, kept up to date by LoadNamespaceVersions and the
+// versions bumped by the other replicas.
+type cacheKeyspace struct {
+	mu       sync.RWMutex
+	versions map[string]int64
+}
+
+func newCacheKeyspace() *cacheKeyspace {
+	return &cacheKeyspace{versions: make(map[string]int64)}
+}
+
+// CreateCacheKey builds the key of a value of the namespace. The value is wrapped in a hash tag, so the keys derived
+// from a cache key such as its lock and stale meta live in the same redis cluster slot. Once the namespace version
+// was bumped it is appended to the key, e.g. mazharul-islam_dev_{cache:object:user:id:1}:v2, so the keys of the
+// previous version are no longer read and simply expire.
+func (cache *cacheManager) CreateCacheKey(namespace, value string) string {
+	cacheKey := utils.WriteStringTemplate("%s_%s_{%s}", cache.prefixCacheKey, cache.environment,
+		cacheKeyReplacer.ReplaceAllString(value, "_"))
+
+	if version := cache.keyspace.version(namespace); version > 0 {
+		cacheKey = utils.WriteStringTemplate("%s:v%d", cacheKey, version)
+	}
+
+	return cacheKey
+}
+
+func (keyspace *cacheKeyspace) version(namespace string) int64 {
+	keyspace.mu.RLock()
+	defer keyspace.mu.RUnlock()
+
+	return keyspace.versions[namespace]
+}
+
+// setVersions stores the versions, a version never goes back so a late reply does not revive old keys.
+func (keyspace *cacheKeyspace) setVersions(versions map[string]int64) {
+	keyspace.mu.Lock()
+	defer keyspace.mu.Unlock()
+
+	for namespace, version := range versions {
+		keyspace.versions[namespace] = max(keyspace.versions[namespace], version)
+	}
+}
+
+func (keyspace *cacheKeyspace) snapshot() map[string]int64 {
+	keyspace.mu.RLock()
+	defer keyspace.mu.RUnlock()
+
+	versions := make(map[string]int64, len(namespaces))
+	for _, namespace := range namespaces {
+		versions[namespace] = keyspace.versions[namespace]
+	}
+
+	return versions
+}
+
+// LoadNamespaceVersions reads the namespace versions from redis, it is called again whenever the invalidation
+// listener reconnects since a bump may have been missed.
+func (cache *cacheManager) LoadNamespaceVersions(ctx context.Context) error {
+	if cache.disableCaching {
+		return nil
+	}
+
+	client, err := cache.getConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer utils.WrapCloser(client.Close)
+
+	versions, err := redigo.Int64Map(redigo.DoContext(client, ctx, "HGETALL", cache.namespacesKey()))
+	if err != nil {
+		return err
+	}
+
+	cache.keyspace.setVersions(versions)
+	return nil
+}
+
+// NamespaceVersions returns the current version of every namespace.
+func (cache *cacheManager) NamespaceVersions() map[string]int64 {
+	return cache.keyspace.snapshot()
+}
+
+// BumpNamespaceVersion invalidates every key of the namespace at once by moving it to a new version. The other
+// replicas are told through the invalidation channel.
+func (cache *cacheManager) BumpNamespaceVersion(ctx context.Context, namespace string) (version int64, err error) {
+	if !utils.Contains(namespaces, namespace) {
+		return 0, ErrUnknownNamespace
+	}
+
+	if cache.disableCaching {
+		return 0, nil
+	}
+
+	ctx, span := startSpan(ctx, "BumpNamespaceVersion", namespace)
+	defer func() { endSpan(span, err) }()
+
+	client, err := cache.getConn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer utils.WrapCloser(client.Close)
+
+	version, err = redigo.Int64(redigo.DoContext(client, ctx, "HINCRBY", cache.namespacesKey(), namespace, 1))
+	if err != nil {
+		return 0, err
+	}
+
+	cache.keyspace.setVersions(map[string]int64{namespace: version})
+	cache.publishInvalidation(invalidationMessage{Namespace: namespace, Version: version})
+
+	return version, nil
+}
+
+func (cache *cacheManager) namespacesKey() string {
+	return utils.WriteStringTemplate("%s_%s_cache:namespaces", cache.prefixCacheKey, cache.environment)
+}
@@ -0,0 +1,390 @@
+package cacher
+
+import (
+	"context"
+	"time"
+
+	redigo "github.com/gomodule/redigo/redis"
+	"github.com/google/uuid"
+	"github.com/mazharul-islam/utils"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	PurgeJobRunning   PurgeJobStatus = "running"
+	PurgeJobCompleted PurgeJobStatus = "completed"
+	PurgeJobCancelled PurgeJobStatus = "cancelled"
+	PurgeJobFailed    PurgeJobStatus = "failed"
+)
+
+type (
+	PurgeJobStatus string
+
+	// PurgeJob is the progress of a purge running in background.
+	PurgeJob struct {
+		ID         string         `json:"id"`
+		Pattern    string         `json:"pattern"`
+		Status     PurgeJobStatus `json:"status"`
+		Deleted    int64          `json:"deleted"`
+		Error      string         `json:"error,omitempty"`
+		StartedAt  time.Time      `json:"startedAt"`
+		FinishedAt *time.Time     `json:"finishedAt,omitempty"`
+	}
+
+	// purgeJobHash is how a PurgeJob is stored in redis, so the progress and the cancel flag are updated field by field
+	purgeJobHash struct {
+		Pattern    string `redis:"pattern"`
+		Status     string `redis:"status"`
+		Deleted    int64  `redis:"deleted"`
+		Error      string `redis:"error"`
+		StartedAt  int64  `redis:"started_at"`
+		FinishedAt int64  `redis:"finished_at"`
+		Cancel     bool   `redis:"cancel"`
+
+		// Owner is the instance running the job, HeartbeatAt the last time it reported the job alive
+		Owner       string `redis:"owner"`
+		HeartbeatAt int64  `redis:"heartbeat_at"`
+	}
+)
+
+// failStalePurgeJobScript fails a running job whose owner stopped sending heartbeats, so the job is not reported
+// running forever after its replica was restarted. A job finished meanwhile by its owner is left untouched.
+// KEYS[1]: job key, ARGV[1]: heartbeats before this time in ms are stale, ARGV[2]: error, ARGV[3]: finished at in ms.
+// Returns 1 when the job was failed
+var failStalePurgeJobScript = redigo.NewScript(1, `
+local job = redis.call('HMGET', KEYS[1], 'status', 'heartbeat_at')
+if job[1] ~= 'running' or (tonumber(job[2]) or 0) >= tonumber(ARGV[1]) then
+	return 0
+end
+
+redis.call('HSET', KEYS[1], 'status', 'failed', 'error', ARGV[2], 'finished_at', ARGV[3])
+return 1
+`)
+
+// Purge is used to remove all cache items that match a given pattern, it returns the number of deleted items.
+func (cache *cacheManager) Purge(matchString string) (int64, error) {
+	return cache.PurgeCtx(context.Background(), matchString)
+}
+
+// PurgeCtx is the context aware variant of Purge. Keys are scanned and unlinked in bounded batches, so redis keeps
+// serving other clients and frees the memory in background. The purge stops when the context is done, the keys
+// deleted so far are still reported.
+func (cache *cacheManager) PurgeCtx(ctx context.Context, matchString string) (deleted int64, err error) {
+	if cache.disableCaching {
+		return 0, nil
+	}
+
+	ctx, span := startSpan(ctx, "Purge", matchString)
+	defer func() { endSpan(span, err) }()
+
+	return cache.purge(ctx, matchString, nil)
+}
+
+// purge unlinks the keys matching the pattern, calling progress after each batch. It stops at the first error of
+// progress, e.g. when the purge job is cancelled.
+func (cache *cacheManager) purge(ctx context.Context, matchString string, progress func(deleted int64) error) (deleted int64, err error) {
+	// the local caches may hold keys deleted before a failure
+	defer cache.invalidateLocalPattern(matchString)
+
+	client, err := cache.getConn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer utils.WrapCloser(client.Close)
+
+	cursor := "0"
+	for {
+		if err := ctx.Err(); err != nil {
+			return deleted, err
+		}
+
+		res, err := redigo.Values(redigo.DoContext(client, ctx, "SCAN", cursor, "MATCH", matchString, "COUNT", purgeScanCount))
+		if err != nil {
+			return deleted, err
+		}
+
+		cursor, _ = redigo.String(res[0], nil)
+		foundKeys, _ := redigo.Strings(res[1], nil)
+
+		for i := 0; i < len(foundKeys); i += purgeBatchSize {
+			batch := foundKeys[i:min(i+purgeBatchSize, len(foundKeys))]
+			unlinked, err := redigo.Int64(redigo.DoContext(client, ctx, "UNLINK", redigo.Args{}.AddFlat(batch)...))
+			if err != nil {
+				return deleted, err
+			}
+
+			deleted += unlinked
+		}
+
+		if progress != nil {
+			if err := progress(deleted); err != nil {
+				return deleted, err
+			}
+		}
+
+		if cursor == "0" {
+			return deleted, nil
+		}
+	}
+}
+
+// PurgeAsync starts purging the keys matching the pattern in background, the returned job is used to follow the
+// progress with GetPurgeJob from any replica. There is no worker process, the purge runs in the process which
+// started it until it completes or is cancelled. A job is not resumed when its process stops: it is failed once
+// its heartbeats stop, see FailOrphanedPurgeJobs, and the purge must be started again.
+func (cache *cacheManager) PurgeAsync(ctx context.Context, matchString string) (PurgeJob, error) {
+	job := PurgeJob{
+		ID:        uuid.NewString(),
+		Pattern:   matchString,
+		Status:    PurgeJobRunning,
+		StartedAt: time.Now(),
+	}
+
+	if cache.disableCaching {
+		job.Status = PurgeJobCompleted
+		job.FinishedAt = &job.StartedAt
+		return job, nil
+	}
+
+	client, err := cache.getConn(ctx)
+	if err != nil {
+		return PurgeJob{}, err
+	}
+	defer utils.WrapCloser(client.Close)
+
+	jobKey := cache.purgeJobKey(job.ID)
+	hash := purgeJobHash{
+		Pattern:     matchString,
+		Status:      string(PurgeJobRunning),
+		StartedAt:   job.StartedAt.UnixMilli(),
+		Owner:       cache.instanceID,
+		HeartbeatAt: job.StartedAt.UnixMilli(),
+	}
+	if _, err := redigo.DoContext(client, ctx, "HSET", redigo.Args{}.Add(jobKey).AddFlat(&hash)...); err != nil {
+		return PurgeJob{}, err
+	}
+
+	if _, err := redigo.DoContext(client, ctx, "EXPIRE", jobKey, int64(purgeJobTTL.Seconds())); err != nil {
+		return PurgeJob{}, err
+	}
+
+	if _, err := redigo.DoContext(client, ctx, "SADD", cache.runningPurgeJobsKey(), job.ID); err != nil {
+		return PurgeJob{}, err
+	}
+
+	go cache.runPurgeJob(job)
+
+	return job, nil
+}
+
+func (cache *cacheManager) runPurgeJob(job PurgeJob) {
+	logger := logrus.WithFields(logrus.Fields{"purgeJobID": job.ID, "pattern": job.Pattern})
+	jobKey := cache.purgeJobKey(job.ID)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go cache.sendPurgeJobHeartbeats(ctx, jobKey)
+
+	deleted, err := cache.purge(ctx, job.Pattern, func(deleted int64) error {
+		client := cache.conn()
+		defer utils.WrapCloser(client.Close)
+
+		if _, err := client.Do("HSET", jobKey, "deleted", deleted); err != nil {
+			return err
+		}
+
+		cancelled, err := redigo.Bool(client.Do("HGET", jobKey, "cancel"))
+		if err != nil && err != redigo.ErrNil {
+			return err
+		}
+
+		if cancelled {
+			return context.Canceled
+		}
+
+		return nil
+	})
+
+	status, errMessage := PurgeJobCompleted, ""
+	switch {
+	case err == context.Canceled:
+		status = PurgeJobCancelled
+	case err != nil:
+		status, errMessage = PurgeJobFailed, err.Error()
+		logger.Error(err)
+	}
+
+	client := cache.conn()
+	defer utils.WrapCloser(client.Close)
+
+	if _, err := client.Do("HSET", jobKey,
+		"status", status,
+		"deleted", deleted,
+		"error", errMessage,
+		"finished_at", time.Now().UnixMilli(),
+	); err != nil {
+		logger.Error(err)
+	}
+
+	if _, err := client.Do("SREM", cache.runningPurgeJobsKey(), job.ID); err != nil {
+		logger.Error(err)
+	}
+}
+
+// sendPurgeJobHeartbeats reports the job alive until the context is done.
+func (cache *cacheManager) sendPurgeJobHeartbeats(ctx context.Context, jobKey string) {
+	ticker := time.NewTicker(purgeJobHeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		client := cache.conn()
+		if _, err := client.Do("HSET", jobKey, "heartbeat_at", time.Now().UnixMilli()); err != nil {
+			logrus.WithField("purgeJobKey", jobKey).Error(err)
+		}
+		utils.WrapCloser(client.Close)
+	}
+}
+
+// GetPurgeJob returns the progress of a purge started with PurgeAsync.
+func (cache *cacheManager) GetPurgeJob(ctx context.Context, id string) (PurgeJob, error) {
+	if cache.disableCaching {
+		return PurgeJob{}, ErrPurgeJobNotFound
+	}
+
+	client, err := cache.getConn(ctx)
+	if err != nil {
+		return PurgeJob{}, err
+	}
+	defer utils.WrapCloser(client.Close)
+
+	jobKey := cache.purgeJobKey(id)
+	if _, err := failStalePurgeJob(ctx, client, jobKey); err != nil {
+		return PurgeJob{}, err
+	}
+
+	values, err := redigo.Values(redigo.DoContext(client, ctx, "HGETALL", jobKey))
+	if err != nil {
+		return PurgeJob{}, err
+	}
+
+	if len(values) == 0 {
+		return PurgeJob{}, ErrPurgeJobNotFound
+	}
+
+	var hash purgeJobHash
+	if err := redigo.ScanStruct(values, &hash); err != nil {
+		return PurgeJob{}, err
+	}
+
+	return hash.toPurgeJob(id), nil
+}
+
+// CancelPurgeJob asks a purge started with PurgeAsync to stop after its current batch.
+// It returns ErrPurgeJobNotRunning when the job already finished or its replica stopped.
+func (cache *cacheManager) CancelPurgeJob(ctx context.Context, id string) error {
+	job, err := cache.GetPurgeJob(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if job.Status != PurgeJobRunning {
+		return ErrPurgeJobNotRunning
+	}
+
+	client, err := cache.getConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer utils.WrapCloser(client.Close)
+
+	_, err = redigo.DoContext(client, ctx, "HSET", cache.purgeJobKey(id), "cancel", true)
+	return err
+}
+
+// FailOrphanedPurgeJobs fails the running jobs whose process stopped sending heartbeats, e.g. the jobs of a replica
+// restarted by a deploy, and returns how many were failed. It is run on startup, GetPurgeJob fails the jobs
+// orphaned later when they are read.
+func (cache *cacheManager) FailOrphanedPurgeJobs(ctx context.Context) (failed int, err error) {
+	if cache.disableCaching {
+		return 0, nil
+	}
+
+	client, err := cache.getConn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer utils.WrapCloser(client.Close)
+
+	ids, err := redigo.Strings(redigo.DoContext(client, ctx, "SMEMBERS", cache.runningPurgeJobsKey()))
+	if err != nil {
+		return 0, err
+	}
+
+	for _, id := range ids {
+		jobKey := cache.purgeJobKey(id)
+		stale, err := failStalePurgeJob(ctx, client, jobKey)
+		if err != nil {
+			return failed, err
+		}
+
+		if stale {
+			failed++
+		}
+
+		status, err := redigo.String(redigo.DoContext(client, ctx, "HGET", jobKey, "status"))
+		if err != nil && err != redigo.ErrNil {
+			return failed, err
+		}
+
+		// the job expired or finished, it is no longer followed
+		if status != string(PurgeJobRunning) {
+			if _, err := redigo.DoContext(client, ctx, "SREM", cache.runningPurgeJobsKey(), id); err != nil {
+				return failed, err
+			}
+		}
+	}
+
+	return failed, nil
+}
+
+// failStalePurgeJob fails the job when it is running but its heartbeats stopped, it returns whether it was failed.
+func failStalePurgeJob(ctx context.Context, client redigo.Conn, jobKey string) (bool, error) {
+	now := time.Now()
+
+	return redigo.Bool(failStalePurgeJobScript.DoContext(ctx, client, jobKey, now.Add(-purgeJobStaleAfter).UnixMilli(),
+		"the replica running the purge job stopped", now.UnixMilli()))
+}
+
+// runningPurgeJobsKey is the set of the ids of the jobs started and not finished yet
+func (cache *cacheManager) runningPurgeJobsKey() string {
+	return utils.WriteStringTemplate("%s_%s_cache:purge_jobs:running", cache.prefixCacheKey, cache.environment)
+}
+
+func (cache *cacheManager) purgeJobKey(id string) string {
+	return utils.WriteStringTemplate("%s_%s_cache:purge:%s", cache.prefixCacheKey, cache.environment, id)
+}
+
+func (hash purgeJobHash) toPurgeJob(id string) PurgeJob {
+	job := PurgeJob{
+		ID:        id,
+		Pattern:   hash.Pattern,
+		Status:    PurgeJobStatus(hash.Status),
+		Deleted:   hash.Deleted,
+		Error:     hash.Error,
+		StartedAt: time.UnixMilli(hash.StartedAt),
+	}
+
+	if hash.FinishedAt > 0 {
+		finishedAt := time.UnixMilli(hash.FinishedAt)
+		job.FinishedAt = &finishedAt
+	}
+
+	return job
+}
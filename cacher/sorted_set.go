@@ -0,0 +1,220 @@
+package cacher
+
+import (
+	"context"
+	"strconv"
+
+	redigo "github.com/gomodule/redigo/redis"
+	"github.com/mazharul-islam/utils"
+)
+
+// ScoredMember is a member of a sorted set with its score.
+type ScoredMember struct {
+	Member string  `json:"member"`
+	Score  float64 `json:"score"`
+}
+
+// AddScore is used to set the score of a member of a sorted set, the sorted set does not expire.
+func (cache *cacheManager) AddScore(ctx context.Context, key, member string, score float64) (err error) {
+	if cache.skipCache() {
+		return nil
+	}
+
+	ctx, span := startSpan(ctx, "AddScore", key)
+	defer func() { endSpan(span, err) }()
+
+	client, err := cache.getConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer utils.WrapCloser(client.Close)
+
+	_, err = redigo.DoContext(client, ctx, "ZADD", key, score, member)
+	return err
+}
+
+// IncrementScore is used to add delta to the score of a member of a sorted set, a missing member starts from 0.
+// It returns the new score.
+func (cache *cacheManager) IncrementScore(ctx context.Context, key, member string, delta float64) (score float64, err error) {
+	if cache.skipCache() {
+		return 0, nil
+	}
+
+	ctx, span := startSpan(ctx, "IncrementScore", key)
+	defer func() { endSpan(span, err) }()
+
+	client, err := cache.getConn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer utils.WrapCloser(client.Close)
+
+	return redigo.Float64(redigo.DoContext(client, ctx, "ZINCRBY", key, delta, member))
+}
+
+// TopMembers is used to retrieve the count members of a sorted set with the highest scores, highest first.
+func (cache *cacheManager) TopMembers(ctx context.Context, key string, count int64) (members []ScoredMember, err error) {
+	if cache.skipCache() || count <= 0 {
+		return nil, nil
+	}
+
+	ctx, span := startSpan(ctx, "TopMembers", key)
+	defer func() { endSpan(span, err) }()
+
+	client, err := cache.getConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer utils.WrapCloser(client.Close)
+
+	return scoredMembers(redigo.DoContext(client, ctx, "ZREVRANGE", key, 0, count-1, "WITHSCORES"))
+}
+
+// MemberRank is used to retrieve the rank of a member of a sorted set, 0 being the highest score.
+// found is false when the member is not in the sorted set.
+func (cache *cacheManager) MemberRank(ctx context.Context, key, member string) (rank int64, found bool, err error) {
+	if cache.skipCache() {
+		return 0, false, nil
+	}
+
+	ctx, span := startSpan(ctx, "MemberRank", key)
+	defer func() { endSpan(span, err) }()
+
+	client, err := cache.getConn(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	defer utils.WrapCloser(client.Close)
+
+	rank, err = redigo.Int64(redigo.DoContext(client, ctx, "ZREVRANK", key, member))
+	switch err {
+	case nil:
+		return rank, true, nil
+	case redigo.ErrNil:
+		return 0, false, nil
+	default:
+		return 0, false, err
+	}
+}
+
+// MemberScores is used to retrieve the scores of members of a sorted set, missing members are left out.
+func (cache *cacheManager) MemberScores(ctx context.Context, key string, members []string) (scores map[string]float64, err error) {
+	scores = make(map[string]float64, len(members))
+	if cache.skipCache() || len(members) == 0 {
+		return scores, nil
+	}
+
+	ctx, span := startSpan(ctx, "MemberScores", key)
+	defer func() { endSpan(span, err) }()
+
+	client, err := cache.getConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer utils.WrapCloser(client.Close)
+
+	values, err := redigo.Values(redigo.DoContext(client, ctx, "ZMSCORE", redigo.Args{}.Add(key).AddFlat(members)...))
+	if err != nil {
+		return nil, err
+	}
+
+	for i, value := range values {
+		if value == nil {
+			continue
+		}
+
+		if scores[members[i]], err = redigo.Float64(value, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return scores, nil
+}
+
+// MembersByScore is used to retrieve up to count members of a sorted set with a score between min and max included,
+// highest first, skipping the first offset members. A negative count returns every member.
+func (cache *cacheManager) MembersByScore(ctx context.Context, key string, min, max float64, offset, count int64) (members []ScoredMember, err error) {
+	if cache.skipCache() {
+		return nil, nil
+	}
+
+	ctx, span := startSpan(ctx, "MembersByScore", key)
+	defer func() { endSpan(span, err) }()
+
+	client, err := cache.getConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer utils.WrapCloser(client.Close)
+
+	return scoredMembers(redigo.DoContext(client, ctx, "ZREVRANGEBYSCORE", key, formatScore(max), formatScore(min),
+		"WITHSCORES", "LIMIT", offset, count))
+}
+
+// CountMembers is used to retrieve the number of members of a sorted set.
+func (cache *cacheManager) CountMembers(ctx context.Context, key string) (count int64, err error) {
+	if cache.skipCache() {
+		return 0, nil
+	}
+
+	ctx, span := startSpan(ctx, "CountMembers", key)
+	defer func() { endSpan(span, err) }()
+
+	client, err := cache.getConn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer utils.WrapCloser(client.Close)
+
+	return redigo.Int64(redigo.DoContext(client, ctx, "ZCARD", key))
+}
+
+// TrimMembers is used to keep only the size members of a sorted set with the highest scores.
+// It returns the number of removed members.
+func (cache *cacheManager) TrimMembers(ctx context.Context, key string, size int64) (removed int64, err error) {
+	if cache.skipCache() {
+		return 0, nil
+	}
+
+	ctx, span := startSpan(ctx, "TrimMembers", key)
+	defer func() { endSpan(span, err) }()
+
+	client, err := cache.getConn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer utils.WrapCloser(client.Close)
+
+	// ranks are ascending, the lowest scores come first
+	return redigo.Int64(redigo.DoContext(client, ctx, "ZREMRANGEBYRANK", key, 0, -size-1))
+}
+
+// scoredMembers converts a WITHSCORES reply.
+func scoredMembers(reply any, err error) ([]ScoredMember, error) {
+	values, err := redigo.Values(reply, err)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]ScoredMember, 0, len(values)/2)
+	for i := 0; i+1 < len(values); i += 2 {
+		member, err := redigo.String(values[i], nil)
+		if err != nil {
+			return nil, err
+		}
+
+		score, err := redigo.Float64(values[i+1], nil)
+		if err != nil {
+			return nil, err
+		}
+
+		members = append(members, ScoredMember{Member: member, Score: score})
+	}
+
+	return members, nil
+}
+
+// formatScore writes infinite scores the way redis expects them.
+func formatScore(score float64) string {
+	return strconv.FormatFloat(score, 'g', -1, 64)
+}
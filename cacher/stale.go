@@ -0,0 +1,147 @@
+package cacher
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+
+	redigo "github.com/gomodule/redigo/redis"
+	"github.com/mazharul-islam/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// staleMeta is stored next to a soft TTL item, in milliseconds
+type staleMeta struct {
+	SoftExpiredAt int64 `json:"soft_expired_at"`
+	Delta         int64 `json:"delta"`
+}
+
+// getStale returns the cached value of a soft TTL item even when it is stale, and starts a single background
+// refresh once the soft TTL has passed. found is false when the key does not exist at all.
+func (cache *cacheManager) getStale(ctx context.Context, key string, fn GetterCtxFn, opts []func(Item), beta float64) (value []byte, found bool, err error) {
+	value, meta, err := cache.getWithStaleMeta(ctx, key)
+	if err == ErrKeyNotExist {
+		// counted by the GetOrLock fallback
+		return nil, false, nil
+	}
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	cache.recordLookup(key, value)
+
+	if meta != nil && meta.shouldRefresh(beta) {
+		cache.revalidate(ctx, key, fn, opts)
+	}
+
+	return value, true, nil
+}
+
+// revalidate refreshes the key in background. The redsync lock makes sure only one refresh runs across replicas.
+func (cache *cacheManager) revalidate(ctx context.Context, key string, fn GetterCtxFn, opts []func(Item)) {
+	if _, running := cache.revalidating.LoadOrStore(key, struct{}{}); running {
+		return
+	}
+
+	// the refresh outlives the request, keep the trace but not the cancellation
+	ctx = context.WithoutCancel(ctx)
+
+	go func() {
+		defer cache.revalidating.Delete(key)
+
+		mutex, err := cache.AcquireLockCtx(ctx, key)
+		if err != nil {
+			// another replica is refreshing the key
+			return
+		}
+
+		if _, err := cache.loadAndStore(ctx, mutex, key, fn, opts); err != nil {
+			logrus.WithField("cacheKey", key).Error(err)
+		}
+	}()
+}
+
+// keepPastSoftTTL makes sure the item lives longer than its soft TTL, so it can be served while refreshed.
+func (cache *cacheManager) keepPastSoftTTL(item Item) {
+	softTTL := item.GetSoftTTL()
+	if time.Duration(cache.decideCacheTTL(item))*time.Second <= softTTL {
+		item.SetTTL(2 * softTTL)
+	}
+}
+
+// storeStaleMeta stores when the item becomes stale, once the item itself was stored.
+// delta is how long the getter function took, used to decide early expiry.
+func (cache *cacheManager) storeStaleMeta(ctx context.Context, item Item, delta time.Duration) {
+	softTTL := item.GetSoftTTL()
+
+	meta := staleMeta{
+		SoftExpiredAt: time.Now().Add(softTTL).UnixMilli(),
+		Delta:         delta.Milliseconds(),
+	}
+
+	client, err := cache.getConn(ctx)
+	if err != nil {
+		logrus.WithField("cacheKey", item.GetKey()).Error(err)
+		return
+	}
+	defer utils.WrapCloser(client.Close)
+
+	if _, err := redigo.DoContext(client, ctx, "SETEX", staleMetaKey(item.GetKey()), cache.decideCacheTTL(item), utils.Dump(meta)); err != nil {
+		logrus.WithField("cacheKey", item.GetKey()).Error(err)
+	}
+}
+
+func (cache *cacheManager) getWithStaleMeta(ctx context.Context, key string) (value []byte, meta *staleMeta, err error) {
+	client, err := cache.getConn(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer utils.WrapCloser(client.Close)
+
+	// the meta key lives in another cluster slot, both are read in a pipeline instead of a transaction
+	if err := client.Send("GET", key); err != nil {
+		return nil, nil, err
+	}
+
+	if err := client.Send("GET", staleMetaKey(key)); err != nil {
+		return nil, nil, err
+	}
+
+	res, err := receiveReplies(ctx, client, 2)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	value, ok := res[0].([]byte)
+	if !ok {
+		return nil, nil, ErrKeyNotExist
+	}
+
+	// items written without soft TTL have no meta and are considered fresh
+	if rawMeta, ok := res[1].([]byte); ok {
+		meta = &staleMeta{}
+		if err := utils.JSONUnmarshal(rawMeta, meta); err != nil {
+			logrus.WithField("cacheKey", key).Error(err)
+			meta = nil
+		}
+	}
+
+	return value, meta, nil
+}
+
+// shouldRefresh implements probabilistic early expiration, the closer to the soft expiry and the slower the getter
+// function, the more likely a refresh starts early. Without beta the item is refreshed exactly at its soft expiry.
+func (meta *staleMeta) shouldRefresh(beta float64) bool {
+	now := time.Now().UnixMilli()
+	if beta <= 0 {
+		return now >= meta.SoftExpiredAt
+	}
+
+	return float64(now)-float64(meta.Delta)*beta*math.Log(rand.Float64()) >= float64(meta.SoftExpiredAt)
+}
+
+func staleMetaKey(key string) string {
+	return key + ":swr"
+}
@@ -0,0 +1,225 @@
+package cacher
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	redigo "github.com/gomodule/redigo/redis"
+	"github.com/mazharul-islam/utils"
+)
+
+const (
+	// sampleKeysScanCount is the SCAN hint used while sampling keys
+	sampleKeysScanCount = 1000
+
+	// sampleKeysMaxScans bounds the SCAN iterations of a sample, so sparse prefixes do not walk the whole key space
+	sampleKeysMaxScans = 100
+)
+
+type (
+	// PrefixStats is the snapshot of the counters of a key prefix.
+	PrefixStats struct {
+		Hits        int64 `json:"hits"`
+		Misses      int64 `json:"misses"`
+		NilHits     int64 `json:"nilHits"`
+		LockWaits   int64 `json:"lockWaits"`
+		WaitTooLong int64 `json:"waitTooLong"`
+		LockLost    int64 `json:"lockLost"`
+	}
+
+	// KeyInfo describes a sampled cache key.
+	KeyInfo struct {
+		Key string `json:"key"`
+		// TTL in seconds, -1 when the key has no expiry
+		TTL int64 `json:"ttl"`
+		// Size in bytes as reported by MEMORY USAGE
+		Size int64 `json:"size"`
+		// Envelope is the metadata of versioned values
+		Envelope *EnvelopeMeta `json:"envelope,omitempty"`
+	}
+
+	// cacheStats holds the counters of every key prefix
+	cacheStats struct {
+		prefixes sync.Map
+	}
+
+	prefixCounters struct {
+		hits        atomic.Int64
+		misses      atomic.Int64
+		nilHits     atomic.Int64
+		lockWaits   atomic.Int64
+		waitTooLong atomic.Int64
+		lockLost    atomic.Int64
+	}
+)
+
+// Stats returns the hit, miss, nil hit, lock wait, ErrWaitTooLong and lost lock counters by key prefix since startup.
+func (cache *cacheManager) Stats() map[string]PrefixStats {
+	return cache.stats.snapshot()
+}
+
+// SampleKeys returns up to count keys matching the prefix, with their TTL, size and envelope metadata.
+func (cache *cacheManager) SampleKeys(ctx context.Context, prefix string, count int) (keys []KeyInfo, err error) {
+	if cache.disableCaching || count <= 0 {
+		return nil, nil
+	}
+
+	ctx, span := startSpan(ctx, "SampleKeys", prefix)
+	defer func() { endSpan(span, err) }()
+
+	client, err := cache.getConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer utils.WrapCloser(client.Close)
+
+	var sampledKeys []string
+	cursor := "0"
+	for scans := 0; scans < sampleKeysMaxScans && len(sampledKeys) < count; scans++ {
+		res, err := redigo.Values(redigo.DoContext(client, ctx, "SCAN", cursor, "MATCH", prefix+"*", "COUNT", sampleKeysScanCount))
+		if err != nil {
+			return nil, err
+		}
+
+		cursor, _ = redigo.String(res[0], nil)
+		foundKeys, _ := redigo.Strings(res[1], nil)
+		sampledKeys = append(sampledKeys, foundKeys...)
+
+		if cursor == "0" {
+			break
+		}
+	}
+
+	if len(sampledKeys) > count {
+		sampledKeys = sampledKeys[:count]
+	}
+
+	for _, key := range sampledKeys {
+		if err := client.Send("TTL", key); err != nil {
+			return nil, err
+		}
+
+		if err := client.Send("MEMORY", "USAGE", key); err != nil {
+			return nil, err
+		}
+
+		if err := client.Send("GETRANGE", key, 0, envelopeHeaderMaxSize-1); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := client.Flush(); err != nil {
+		return nil, err
+	}
+
+	keys = make([]KeyInfo, 0, len(sampledKeys))
+	for _, key := range sampledKeys {
+		ttl, err := redigo.Int64(redigo.ReceiveContext(client, ctx))
+		if err != nil {
+			return nil, err
+		}
+
+		// the size is nil when the key expired in between
+		size, err := redigo.Int64(redigo.ReceiveContext(client, ctx))
+		if err != nil && err != redigo.ErrNil {
+			return nil, err
+		}
+
+		// hashes, sets and sorted sets reply with a WRONGTYPE error
+		header, err := redigo.Bytes(redigo.ReceiveContext(client, ctx))
+		if _, wrongType := err.(redigo.Error); err != nil && !wrongType {
+			return nil, err
+		}
+
+		// -2 means the key expired in between
+		if ttl == -2 {
+			continue
+		}
+
+		envelope, _, _ := parseEnvelope(header)
+		keys = append(keys, KeyInfo{Key: key, TTL: ttl, Size: size, Envelope: envelope})
+	}
+
+	return keys, nil
+}
+
+func (cache *cacheManager) recordLookup(key string, cachedItem any) {
+	cache.stats.recordLookup(keyFamily(cache.prefixCacheKey, cache.environment, key), cachedItem)
+}
+
+func (cache *cacheManager) recordLockWait(key string) {
+	cache.stats.counters(keyFamily(cache.prefixCacheKey, cache.environment, key)).lockWaits.Add(1)
+}
+
+func (cache *cacheManager) recordWaitTooLong(key string) {
+	cache.stats.counters(keyFamily(cache.prefixCacheKey, cache.environment, key)).waitTooLong.Add(1)
+}
+
+func (cache *cacheManager) recordLockLost(key string) {
+	cache.stats.counters(keyFamily(cache.prefixCacheKey, cache.environment, key)).lockLost.Add(1)
+}
+
+// keyFamily groups keys for statistics, e.g. mazharul-islam_dev_{cache:object:user:id:1} and its versioned
+// mazharul-islam_dev_{cache:object:user:id:1}:v2 are counted as cache:object:user:id.
+func keyFamily(prefixCacheKey, environment, key string) string {
+	key = strings.TrimPrefix(key, utils.WriteStringTemplate("%s_%s_", prefixCacheKey, environment))
+	if strings.HasPrefix(key, "{") {
+		if end := strings.LastIndexByte(key, '}'); end > 0 {
+			key = key[1:end]
+		}
+	}
+
+	if i := strings.LastIndexByte(key, ':'); i > 0 {
+		return key[:i]
+	}
+
+	return key
+}
+
+// recordLookup counts the result of a cache read as a hit, nil hit or miss.
+func (stats *cacheStats) recordLookup(family string, cachedItem any) {
+	counters := stats.counters(family)
+	switch value := cachedItem.(type) {
+	case nil:
+		counters.misses.Add(1)
+	case []byte:
+		if bytes.Equal(value, nilValue) {
+			counters.nilHits.Add(1)
+			return
+		}
+
+		counters.hits.Add(1)
+	default:
+		counters.hits.Add(1)
+	}
+}
+
+func (stats *cacheStats) snapshot() map[string]PrefixStats {
+	snapshot := make(map[string]PrefixStats)
+	stats.prefixes.Range(func(family, value any) bool {
+		counters := value.(*prefixCounters)
+		snapshot[family.(string)] = PrefixStats{
+			Hits:        counters.hits.Load(),
+			Misses:      counters.misses.Load(),
+			NilHits:     counters.nilHits.Load(),
+			LockWaits:   counters.lockWaits.Load(),
+			WaitTooLong: counters.waitTooLong.Load(),
+			LockLost:    counters.lockLost.Load(),
+		}
+		return true
+	})
+
+	return snapshot
+}
+
+func (stats *cacheStats) counters(family string) *prefixCounters {
+	if counters, ok := stats.prefixes.Load(family); ok {
+		return counters.(*prefixCounters)
+	}
+
+	counters, _ := stats.prefixes.LoadOrStore(family, &prefixCounters{})
+	return counters.(*prefixCounters)
+}
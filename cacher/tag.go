@@ -0,0 +1,124 @@
+package cacher
+
+import (
+	"context"
+	"time"
+
+	redigo "github.com/gomodule/redigo/redis"
+	"github.com/mazharul-islam/utils"
+)
+
+// Tag membership is tracked in a sorted set per tag, scored by the unix time the member key expires.
+// Expired members are pruned whenever the tag is written, and the tag set itself expires with its
+// longest living member, so tags are cleaned up together with the keys they point to.
+// Tag sets share a hash tag so the scripts can touch several of them in redis cluster, while the member
+// keys are deleted outside of the scripts since they live in other slots.
+var (
+	// KEYS: tag keys, ARGV[1]: member key, ARGV[2]: member expire at, ARGV[3]: now
+	tagScript = redigo.NewScript(-1, `
+for _, tagKey in ipairs(KEYS) do
+	redis.call('ZREMRANGEBYSCORE', tagKey, '-inf', ARGV[3])
+	redis.call('ZADD', tagKey, ARGV[2], ARGV[1])
+
+	local last = redis.call('ZRANGE', tagKey, -1, -1, 'WITHSCORES')
+	if last[2] == 'inf' then
+		redis.call('PERSIST', tagKey)
+	else
+		redis.call('EXPIREAT', tagKey, last[2])
+	end
+end
+return 1
+`)
+
+	// KEYS: tag keys, ARGV[1]: now. Removes the tag sets and returns their member keys
+	invalidateTagsScript = redigo.NewScript(-1, `
+local members = {}
+for _, tagKey in ipairs(KEYS) do
+	for _, member in ipairs(redis.call('ZRANGEBYSCORE', tagKey, ARGV[1], '+inf')) do
+		table.insert(members, member)
+	end
+
+	redis.call('DEL', tagKey)
+end
+return members
+`)
+)
+
+// InvalidateTags is used to remove every cache item carrying any of the tags.
+func (cache *cacheManager) InvalidateTags(tags ...string) error {
+	return cache.InvalidateTagsCtx(context.Background(), tags...)
+}
+
+// InvalidateTagsCtx is the context aware variant of InvalidateTags.
+func (cache *cacheManager) InvalidateTagsCtx(ctx context.Context, tags ...string) (err error) {
+	if cache.disableCaching || len(tags) == 0 {
+		return nil
+	}
+
+	// the tags are invalidated once redis recovers
+	if cache.breaker.isOpen() {
+		cache.deferInvalidation(nil, tags)
+		return nil
+	}
+
+	ctx, span := startSpan(ctx, "InvalidateTags", "")
+	defer func() { endSpan(span, err) }()
+
+	client, err := cache.getConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer utils.WrapCloser(client.Close)
+
+	args := make([]any, 0, len(tags)+2)
+	args = append(args, len(tags))
+	for _, tag := range tags {
+		args = append(args, cache.tagKey(tag))
+	}
+	args = append(args, time.Now().Unix())
+
+	memberKeys, err := redigo.Strings(invalidateTagsScript.DoContext(ctx, client, args...))
+	if err != nil {
+		return err
+	}
+
+	memberKeys = utils.Unique(memberKeys)
+	for start := 0; start < len(memberKeys); start += tagDeleteBatchSize {
+		batch := memberKeys[start:min(start+tagDeleteBatchSize, len(memberKeys))]
+		if _, err := redigo.DoContext(client, ctx, "DEL", redigo.Args{}.AddFlat(batch)...); err != nil {
+			return err
+		}
+	}
+
+	cache.invalidateLocal(memberKeys...)
+	return nil
+}
+
+// tagItem records the item key as a member of each of its tags.
+func (cache *cacheManager) tagItem(ctx context.Context, client redigo.Conn, key string, tags []string, ttl int64) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	now := time.Now().Unix()
+	expiredAt := utils.IntToString(now + ttl)
+	if ttl <= 0 {
+		expiredAt = "+inf"
+	}
+
+	tags = utils.Unique(tags)
+
+	args := make([]any, 0, len(tags)+4)
+	args = append(args, len(tags))
+	for _, tag := range tags {
+		args = append(args, cache.tagKey(tag))
+	}
+	args = append(args, key, expiredAt, now)
+
+	_, err := tagScript.DoContext(ctx, client, args...)
+	return err
+}
+
+func (cache *cacheManager) tagKey(tag string) string {
+	return utils.WriteStringTemplate("{%s_%s_cache:tag}:%s", cache.prefixCacheKey, cache.environment, tag)
+}
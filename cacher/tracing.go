@@ -0,0 +1,32 @@
+package cacher
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/mazharul-islam/cacher")
+
+// startSpan starts a child span of the context for a cache operation.
+func startSpan(ctx context.Context, operation, key string) (context.Context, trace.Span) {
+	attributes := []attribute.KeyValue{attribute.String("db.system", "redis")}
+	if key != "" {
+		attributes = append(attributes, attribute.String("cache.key", key))
+	}
+
+	return tracer.Start(ctx, "cacher."+operation, trace.WithAttributes(attributes...))
+}
+
+// endSpan records the error, cache misses are not errors, and ends the span.
+func endSpan(span trace.Span, err error) {
+	if err != nil && err != ErrKeyNotExist {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
@@ -0,0 +1,128 @@
+package cacher
+
+import (
+	"bytes"
+	"context"
+)
+
+type (
+	// LoaderFn loads the value of a Typed cache, a nil value is cached as nil.
+	LoaderFn[T any] func(ctx context.Context) (*T, error)
+
+	// Typed is a cache of values of type T on top of a CacheManager. It takes care of the codec, nil values
+	// and locking, so callers never handle raw payloads or mutexes.
+	Typed[T any] struct {
+		cache CacheManager
+		opts  []func(Item)
+	}
+)
+
+// NewTyped creates a Typed cache, the options apply to every value it stores.
+func NewTyped[T any](cache CacheManager, opts ...func(Item)) *Typed[T] {
+	return &Typed[T]{
+		cache: cache,
+		opts:  opts,
+	}
+}
+
+// Get returns the cached value of the key. found is false when the key is not cached,
+// a cached nil value is found with a nil value.
+func (typed *Typed[T]) Get(ctx context.Context, key string) (value *T, found bool, err error) {
+	cachedItem, err := typed.cache.GetCtx(ctx, key)
+	if err != nil || cachedItem == nil {
+		return nil, false, err
+	}
+
+	cachedValue, ok := cachedItem.([]byte)
+	if !ok {
+		return nil, false, ErrInvalidCacheValue
+	}
+
+	value, err = decodeTyped[T](cachedValue)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return value, true, nil
+}
+
+// GetOrLoad returns the cached value of the key, or loads and stores it while holding the key lock,
+// so concurrent callers wait for a single loader.
+func (typed *Typed[T]) GetOrLoad(ctx context.Context, key string, loader LoaderFn[T], opts ...func(Item)) (value *T, err error) {
+	fn := func(ctx context.Context) (any, error) {
+		value, err := loader(ctx)
+		if err != nil || value == nil {
+			// avoid a typed nil, which would not be cached as nil
+			return nil, err
+		}
+
+		return value, nil
+	}
+
+	// the payload is decoded once with the codec of its format header, GetOrSet would convert it to JSON first
+	cache, ok := typed.cache.(*cacheManager)
+	if !ok {
+		cachedValue, err := typed.cache.GetOrSetCtx(ctx, key, fn, typed.options(opts)...)
+		if err != nil || cachedValue == nil {
+			return nil, err
+		}
+
+		return decodeTyped[T](cachedValue)
+	}
+
+	if cache.skipCache() {
+		return loader(ctx)
+	}
+
+	ctx, span := startSpan(ctx, "GetOrLoad", key)
+	defer func() { endSpan(span, err) }()
+
+	cachedValue, err := cache.getOrSet(ctx, key, fn, typed.options(opts))
+	if err != nil || cachedValue == nil {
+		return nil, err
+	}
+
+	return decodeTyped[T](cachedValue)
+}
+
+// Set stores the value of the key, a nil value is cached as nil.
+func (typed *Typed[T]) Set(ctx context.Context, key string, value *T, opts ...func(Item)) error {
+	if value == nil {
+		return typed.cache.StoreNilCtx(ctx, key)
+	}
+
+	cachedValue, err := typed.cache.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	item := NewItem(key, cachedValue)
+	applyVersion(item, value)
+	for _, o := range typed.options(opts) {
+		o(item)
+	}
+
+	return typed.cache.StoreWithoutBlockingCtx(ctx, item)
+}
+
+// Delete removes the cached values of the keys.
+func (typed *Typed[T]) Delete(ctx context.Context, keys ...string) error {
+	return typed.cache.DeleteByKeysCtx(ctx, keys)
+}
+
+func (typed *Typed[T]) options(opts []func(Item)) []func(Item) {
+	return append(append([]func(Item){}, typed.opts...), opts...)
+}
+
+func decodeTyped[T any](cachedValue []byte) (*T, error) {
+	if bytes.Equal(cachedValue, nilValue) {
+		return nil, nil
+	}
+
+	value := new(T)
+	if err := Unmarshal(cachedValue, value); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
@@ -0,0 +1,58 @@
+package cacher
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redsync/redsync/v4"
+)
+
+// watchLock extends the mutex every third of its expiry until the returned stop function is called, so a slow
+// getter function keeps its lock. stop reports ErrLockLost when an extension failed, meaning another loader may
+// have taken over the key in the meantime.
+func watchLock(ctx context.Context, mutex *redsync.Mutex, expiry time.Duration) (stop func() error) {
+	interval := expiry / 3
+	if mutex == nil || interval <= 0 {
+		return func() error { return nil }
+	}
+
+	done := make(chan struct{})
+	lost := make(chan error, 1)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				extended, err := mutex.ExtendContext(ctx)
+				if err != nil {
+					lost <- fmt.Errorf("%w: %s: %v", ErrLockLost, mutex.Name(), err)
+					return
+				}
+
+				if !extended {
+					lost <- fmt.Errorf("%w: %s", ErrLockLost, mutex.Name())
+					return
+				}
+			}
+		}
+	}()
+
+	return func() error {
+		close(done)
+
+		select {
+		case err := <-lost:
+			return err
+		default:
+			return nil
+		}
+	}
+}
@@ -124,6 +124,11 @@ func DatabasePingInterval() time.Duration {
 	return utils.ParseDurationWithDefault(value, DefaultDatabasePingInterval)
 }
 
+func DatabaseMigrationLockTimeout() time.Duration {
+	value := viper.GetString("db.migration_lock_timeout")
+	return utils.ParseDurationWithDefault(value, DefaultDatabaseMigrationLockTimeout)
+}
+
 func GetLogLevel() string {
 	value := viper.GetString("log_level")
 	return utils.ValueOrDefault[string](value, string(commons.LogLevelTrace))
@@ -142,10 +147,22 @@ func SwaggerPassword() string {
 	return viper.GetString("swagger.password")
 }
 
+func BasicAuthUsername() string {
+	return viper.GetString("basic.auth.username")
+}
+
+func BasicAuthPassword() string {
+	return viper.GetString("basic.auth.password")
+}
+
 func RedisCacheHost() string {
 	return viper.GetString("redis.cache_host")
 }
 
+func RedisLockHost() string {
+	return viper.GetString("redis.lock_host")
+}
+
 func EnableCaching() bool {
 	return viper.GetBool("enable_caching")
 }
@@ -169,3 +186,59 @@ func RedisMaxIdleConn() int {
 func RedisMaxActiveConn() int {
 	return utils.ValueOrDefault[int](utils.StringToInt[int](viper.GetString("redis.max_active_conn")), 50)
 }
+
+func CacheDriver() string {
+	return utils.ValueOrDefault[string](viper.GetString("cache_driver"), DefaultCacheDriver)
+}
+
+func CacheCodec() string {
+	return utils.ValueOrDefault[string](viper.GetString("cache_codec"), DefaultCacheCodec)
+}
+
+func CacheCompression() bool {
+	return viper.GetBool("cache_compression")
+}
+
+func EnableRateLimit() bool {
+	return viper.GetBool("rate_limit.enabled")
+}
+
+func RateLimitAlgorithm() string {
+	return utils.ValueOrDefault[string](viper.GetString("rate_limit.algorithm"), DefaultRateLimitAlgorithm)
+}
+
+func RateLimitRequests() int {
+	return utils.ValueOrDefault[int](viper.GetInt("rate_limit.requests"), DefaultRateLimitRequests)
+}
+
+func RateLimitWindow() time.Duration {
+	return utils.ParseDurationWithDefault(viper.GetString("rate_limit.window"), DefaultRateLimitWindow)
+}
+
+func CacheBreakerFailureThreshold() int {
+	return utils.ValueOrDefault[int](viper.GetInt("cache_breaker.failure_threshold"), DefaultCacheBreakerFailureThreshold)
+}
+
+func CacheBreakerProbeInterval() time.Duration {
+	return utils.ParseDurationWithDefault(viper.GetString("cache_breaker.probe_interval"), DefaultCacheBreakerProbeInterval)
+}
+
+func SeenProfilesCapacity() uint {
+	return utils.ValueOrDefault[uint](viper.GetUint("seen_profiles.capacity"), DefaultSeenProfilesCapacity)
+}
+
+func SeenProfilesErrorRate() float64 {
+	return utils.ValueOrDefault[float64](viper.GetFloat64("seen_profiles.error_rate"), DefaultSeenProfilesErrorRate)
+}
+
+func PopularityMaxSize() int64 {
+	return utils.ValueOrDefault[int64](viper.GetInt64("popularity.max_size"), DefaultPopularityMaxSize)
+}
+
+func LocalCacheMaxSize() int {
+	return viper.GetInt("local_cache.max_size")
+}
+
+func LocalCacheTTL() time.Duration {
+	return utils.ParseDurationWithDefault(viper.GetString("local_cache.ttl"), DefaultLocalCacheTTL)
+}
@@ -9,6 +9,8 @@ const (
 	DefaultDatabasePingInterval    = 5 * time.Second
 	DefaultDatabaseRetryAttempts   = 3
 
+	DefaultDatabaseMigrationLockTimeout = 5 * time.Minute
+
 	DefaultWorkerRetryAttempts = 3
 	DefaultWorkerTaskRetention = 1 * time.Hour
 	DefaultWorkerConcurrency   = 25
@@ -23,4 +25,20 @@ const (
 
 	DefaultRedisLockDuration  = 5 * time.Second
 	DefaultRedisRetryAttempts = 3
+
+	DefaultLocalCacheTTL = 5 * time.Second
+	DefaultCacheCodec    = "json"
+	DefaultCacheDriver   = "redis"
+
+	DefaultCacheBreakerFailureThreshold = 5
+	DefaultCacheBreakerProbeInterval    = 1 * time.Second
+
+	DefaultSeenProfilesCapacity  = 1000
+	DefaultSeenProfilesErrorRate = 0.01
+
+	DefaultPopularityMaxSize = 10000
+
+	DefaultRateLimitAlgorithm = "sliding_window"
+	DefaultRateLimitRequests  = 60
+	DefaultRateLimitWindow    = 1 * time.Minute
 )
@@ -14,4 +14,5 @@ func continueOrFatal(err error) {
 
 var (
 	ErrReceivedInterrupt = errors.New("received an interrupt")
+	ErrNothingToRedo     = errors.New("nothing to redo, no migration is applied")
 )
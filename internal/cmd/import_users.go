@@ -0,0 +1,320 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mazharul-islam/internal/database"
+	"github.com/mazharul-islam/internal/entity"
+	"github.com/mazharul-islam/utils"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const (
+	importFormatCSV   = "csv"
+	importFormatJSONL = "jsonl"
+
+	// importInterestSeparator separates interests inside a single csv column
+	importInterestSeparator = "|"
+)
+
+var (
+	importUsersCmd = &cobra.Command{
+		Use:   "import-users [file]",
+		Short: "import users from csv or jsonl file",
+		Long:  `This subcommand validate and upsert users by external identifier from a csv or jsonl file`,
+		Args:  cobra.ExactArgs(1),
+		Run:   processImportUsers,
+	}
+
+	ErrUnknownImportFormat = errors.New("unknown import format, use csv or jsonl")
+	ErrMissingCSVColumn    = errors.New("missing required csv column")
+)
+
+type (
+	importRow struct {
+		Line    int
+		Request entity.RequestImportUser
+		Err     error
+	}
+
+	importRejection struct {
+		Line       int
+		ExternalID string
+		Reason     string
+	}
+)
+
+func init() {
+	importUsersCmd.Flags().String("format", "", "file format, csv or jsonl. detected from the file extension when empty")
+	importUsersCmd.Flags().Int("batch-size", 500, "number of users upserted per batch")
+	importUsersCmd.Flags().String("report", "", "rejection report path. default is [file].rejected.csv")
+	RootCmd.AddCommand(importUsersCmd)
+}
+
+func processImportUsers(cmd *cobra.Command, args []string) {
+	log.Info("Process import users!")
+
+	path := args[0]
+	flags := cmd.Flags()
+
+	format, err := flags.GetString("format")
+	continueOrFatal(err)
+	batchSize, err := flags.GetInt("batch-size")
+	continueOrFatal(err)
+	reportPath, err := flags.GetString("report")
+	continueOrFatal(err)
+
+	format = utils.ValueOrDefault(format, strings.TrimPrefix(filepath.Ext(path), "."))
+	reportPath = utils.ValueOrDefault(reportPath, path+".rejected.csv")
+	batchSize = utils.ValueOrDefault(batchSize, 500)
+
+	db, err := database.InitializePostgresConnection()
+	continueOrFatal(err)
+
+	cacheManager, closeCache := InitCacheManager()
+	defer closeCache()
+
+	userRepository := InitUserRepository(db, cacheManager)
+
+	var (
+		ctx        = context.Background()
+		imported   int
+		rejections []importRejection
+		batch      []entity.UserRecord
+		batchLines []importRow
+		seen       = make(map[string]bool)
+	)
+
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+
+		if _, err := userRepository.UpsertUsersByExternalID(ctx, batch); err == nil {
+			imported += len(batch)
+		} else {
+			// a single row fails the whole statement, retry row by row to find it
+			for i, row := range batchLines {
+				if len(batch) > 1 {
+					_, err = userRepository.UpsertUsersByExternalID(ctx, batch[i:i+1])
+				}
+
+				if err != nil {
+					rejections = append(rejections, importRejection{row.Line, row.Request.ExternalID, err.Error()})
+					continue
+				}
+
+				imported++
+			}
+		}
+
+		batch, batchLines = nil, nil
+		seen = make(map[string]bool)
+	}
+
+	err = readImportRows(path, format, func(row importRow) {
+		if row.Err == nil {
+			row.Err = row.Request.Validate()
+		}
+
+		if row.Err != nil {
+			rejections = append(rejections, importRejection{row.Line, row.Request.ExternalID, row.Err.Error()})
+			return
+		}
+
+		// postgres can not upsert the same row twice in one statement, later lines win
+		if seen[row.Request.ExternalID] {
+			flush()
+		}
+
+		seen[row.Request.ExternalID] = true
+		batch = append(batch, row.Request.ToUserRecord())
+		batchLines = append(batchLines, row)
+
+		if len(batch) >= batchSize {
+			flush()
+		}
+	})
+	if err != nil {
+		log.WithField("path", path).Fatal("Failed to read import file: ", err)
+	}
+
+	flush()
+
+	if len(rejections) > 0 {
+		if err := writeImportRejections(reportPath, rejections); err != nil {
+			log.WithField("reportPath", reportPath).Error("Failed to write rejection report: ", err)
+		}
+
+		log.WithField("reportPath", reportPath).Warnf("Rejected %d rows!", len(rejections))
+	}
+
+	log.Infof("Imported %d users!\n", imported)
+}
+
+func readImportRows(path, format string, fn func(importRow)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer utils.WrapCloser(file.Close)
+
+	switch utils.StringToLower(format) {
+	case importFormatCSV:
+		return readImportCSV(file, fn)
+	case importFormatJSONL, "ndjson":
+		return readImportJSONL(file, fn)
+	default:
+		return ErrUnknownImportFormat
+	}
+}
+
+func readImportJSONL(reader io.Reader, fn func(importRow)) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		row := importRow{Line: line}
+		row.Err = utils.JSONUnmarshal([]byte(text), &row.Request)
+		fn(row)
+	}
+
+	return scanner.Err()
+}
+
+// readImportCSV reads a csv with a header containing external_id, name, age, gender, latitude,
+// longitude, interests (separated by |) and preferences (json) columns in any order.
+func readImportCSV(reader io.Reader, fn func(importRow)) error {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+
+	header, err := csvReader.Read()
+	if err != nil {
+		return err
+	}
+
+	columns := make(map[string]int, len(header))
+	for idx, name := range header {
+		columns[utils.StringToLower(strings.TrimSpace(name))] = idx
+	}
+
+	for _, required := range []string{"external_id", "name", "age"} {
+		if _, ok := columns[required]; !ok {
+			return fmt.Errorf("%w: %s", ErrMissingCSVColumn, required)
+		}
+	}
+
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			return nil
+		}
+
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			fn(importRow{Line: parseErr.Line, Err: err})
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		line, _ := csvReader.FieldPos(0)
+		value := func(column string) string {
+			idx, ok := columns[column]
+			if !ok || idx >= len(record) {
+				return ""
+			}
+
+			return strings.TrimSpace(record[idx])
+		}
+
+		request, err := toImportRequest(value)
+		fn(importRow{Line: line, Request: request, Err: err})
+	}
+}
+
+func toImportRequest(value func(column string) string) (request entity.RequestImportUser, err error) {
+	request.ExternalID = value("external_id")
+	request.Name = value("name")
+	request.Gender = utils.StringToLower(value("gender"))
+	request.Age = utils.StringToInt[uint](value("age"))
+
+	if request.Latitude, err = parseOptionalFloat(value("latitude")); err != nil {
+		return request, fmt.Errorf("invalid latitude: %w", err)
+	}
+
+	if request.Longitude, err = parseOptionalFloat(value("longitude")); err != nil {
+		return request, fmt.Errorf("invalid longitude: %w", err)
+	}
+
+	if interests := value("interests"); interests != "" {
+		for _, interest := range utils.SplitString(interests, importInterestSeparator) {
+			request.Interests = append(request.Interests, strings.TrimSpace(interest))
+		}
+	}
+
+	if preferences := value("preferences"); preferences != "" {
+		request.Preferences = &entity.Preferences{}
+		if err = utils.JSONUnmarshal([]byte(preferences), request.Preferences); err != nil {
+			return request, fmt.Errorf("invalid preferences: %w", err)
+		}
+	}
+
+	return request, nil
+}
+
+func parseOptionalFloat(value string) (*float64, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return &parsed, nil
+}
+
+func writeImportRejections(path string, rejections []importRejection) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer utils.WrapCloser(file.Close)
+
+	writer := csv.NewWriter(file)
+	if err := writer.Write([]string{"line", "external_id", "reason"}); err != nil {
+		return err
+	}
+
+	for _, rejection := range rejections {
+		if err := writer.Write([]string{utils.IntToString(rejection.Line), rejection.ExternalID, rejection.Reason}); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -1,16 +1,144 @@
 package cmd
 
 import (
+	"context"
+	"sync"
+
 	"github.com/mazharul-islam/cacher"
+	"github.com/mazharul-islam/config"
+	"github.com/mazharul-islam/internal/database"
 	"github.com/mazharul-islam/internal/entity"
 	"github.com/mazharul-islam/internal/repository"
 	"github.com/mazharul-islam/internal/service"
+	"github.com/mazharul-islam/ratelimit"
+	"github.com/mazharul-islam/utils"
+	log "github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
 func InitMatchService(db *gorm.DB, cacher cacher.CacheManager) entity.IMatchService {
-	userRepository := repository.NewUserRepository(db, cacher)
+	userRepository := InitUserRepository(db, cacher)
 	matchService := service.NewMatchService(userRepository)
 
 	return matchService
 }
+
+func InitUserRepository(db *gorm.DB, cacheManager cacher.CacheManager) entity.IUserRepository {
+	seenProfiles, err := cacher.NewBloomFilter(cacheManager, "seen_profiles",
+		config.SeenProfilesCapacity(), config.SeenProfilesErrorRate())
+	continueOrFatal(err)
+
+	return repository.NewUserRepository(db, cacheManager, seenProfiles)
+}
+
+var (
+	memoryRedisOnce sync.Once
+	memoryRedisURL  string
+)
+
+// redisCacheHost returns the url of the cache redis, the memory driver starts a redis in process memory on first use
+func redisCacheHost() string {
+	if config.CacheDriver() != "memory" {
+		return config.RedisCacheHost()
+	}
+
+	memoryRedisOnce.Do(func() {
+		var err error
+		memoryRedisURL, err = database.StartMemoryRedis()
+		continueOrFatal(err)
+	})
+
+	return memoryRedisURL
+}
+
+// InitCacheManager creates the cache manager from config, the returned function closes its connections
+func InitCacheManager() (cacher.CacheManager, func()) {
+	cacheManager := cacher.ConstructCacheManager()
+	cacheManager.SetDisableCaching(!config.EnableCaching())
+	cacheManager.SetCodec(newCacheCodec())
+
+	if !config.EnableCaching() {
+		return cacheManager, func() {}
+	}
+
+	redisDB, err := database.InitializeRedigoRedisConnectionPool(redisCacheHost(), redisOptions)
+	continueOrFatal(err)
+
+	cacheManager.SetConnectionPool(redisDB)
+	cacheManager.SetLocalCache(config.LocalCacheMaxSize(), config.LocalCacheTTL())
+	cacheManager.SetCircuitBreaker(config.CacheBreakerFailureThreshold(), config.CacheBreakerProbeInterval())
+
+	// keys built before the versions are loaded would read the previous versions
+	continueOrFatal(cacheManager.LoadNamespaceVersions(context.Background()))
+
+	// the purges of a stopped replica are not resumed, they are reported as failed
+	if failed, err := cacheManager.FailOrphanedPurgeJobs(context.Background()); err != nil {
+		log.Error(err)
+	} else if failed > 0 {
+		log.Warnf("failed %d orphaned purge jobs", failed)
+	}
+
+	// locks use the cache connection unless they have their own redis
+	lockRedisDB := redisDB
+	if config.RedisLockHost() != "" {
+		lockRedisDB, err = database.InitializeRedigoRedisConnectionPool(config.RedisLockHost(), redisOptions)
+		continueOrFatal(err)
+
+		cacheManager.SetLockConnectionPool(lockRedisDB)
+	}
+
+	// the subscriber waits for messages longer than the read timeout of the cache connections
+	subscriberRedisDB, err := database.InitializeRedigoSubscriberConnectionPool(redisCacheHost(), redisOptions)
+	continueOrFatal(err)
+
+	cacheManager.SetSubscriberConnectionPool(subscriberRedisDB)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		if err := cacheManager.ListenInvalidation(ctx); err != nil {
+			log.Error(err)
+		}
+	}()
+
+	return cacheManager, func() {
+		cancel()
+		utils.WrapCloser(subscriberRedisDB.Close)
+		utils.WrapCloser(redisDB.Close)
+		if lockRedisDB != redisDB {
+			utils.WrapCloser(lockRedisDB.Close)
+		}
+	}
+}
+
+func newCacheCodec() cacher.Codec {
+	codec := cacher.NewJSONCodec()
+	if config.CacheCodec() == "msgpack" {
+		codec = cacher.NewMsgpackCodec()
+	}
+
+	if config.CacheCompression() {
+		codec = cacher.NewGzipCodec(codec)
+	}
+
+	return codec
+}
+
+// InitRateLimiter creates the api rate limiter on the cache redis, it is nil when rate limiting is disabled.
+// The returned function closes its connections
+func InitRateLimiter() (ratelimit.Limiter, func()) {
+	if !config.EnableRateLimit() {
+		return nil, func() {}
+	}
+
+	redisDB, err := database.InitializeRedigoRedisConnectionPool(redisCacheHost(), redisOptions)
+	continueOrFatal(err)
+
+	rateLimiter := ratelimit.NewSlidingWindow(redisDB, "api", config.RateLimitRequests(), config.RateLimitWindow())
+	if config.RateLimitAlgorithm() == "token_bucket" {
+		rateLimiter = ratelimit.NewTokenBucket(redisDB, "api", config.RateLimitRequests(), config.RateLimitWindow())
+	}
+
+	return rateLimiter, func() {
+		utils.WrapCloser(redisDB.Close)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/mazharul-islam/internal/database"
 	"github.com/mazharul-islam/utils"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -22,7 +23,7 @@ var (
 		Run:   processCreateMigration,
 	}
 
-	folderPath = "database/migrations/"
+	folderPath = database.MigrationFolderPath
 )
 
 func init() {
@@ -97,13 +98,6 @@ func checkMigrationFolderExists() error {
 }
 
 func createMigrationFolder() error {
-	_, err := os.Stat("database/")
-	if os.IsNotExist(err) { //check if database folder is already exist
-		if err := os.Mkdir("database/", os.ModePerm); err != nil {
-			return err
-		}
-	}
-
 	if err := os.MkdirAll(folderPath, os.ModePerm); err != nil {
 		return err
 	}
@@ -1,27 +1,58 @@
 package cmd
 
 import (
-	"github.com/mazharul-islam/internal/database"
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
 	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/mazharul-islam/config"
+	"github.com/mazharul-islam/internal/database"
 	"github.com/mazharul-islam/utils"
 	migrate "github.com/rubenv/sql-migrate"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
-var migrateCmd = &cobra.Command{
-	Use:   "migrate",
-	Short: "migrate database",
-	Long:  `This subcommand used to migrate database`,
-	Run:   processMigration,
-}
+var (
+	migrateCmd = &cobra.Command{
+		Use:   "migrate",
+		Short: "migrate database",
+		Long:  `This subcommand used to migrate database`,
+		Run:   processMigration,
+	}
+
+	migrateStatusCmd = &cobra.Command{
+		Use:   "status",
+		Short: "show migration status",
+		Long:  `This subcommand list applied and pending migrations`,
+		Run:   processMigrationStatus,
+	}
+
+	migrateRedoCmd = &cobra.Command{
+		Use:   "redo",
+		Short: "reapply the last migration",
+		Long:  `This subcommand rollback the last applied migration and apply it again`,
+		Run:   processMigrationRedo,
+	}
+)
 
 func init() {
 	migrateCmd.PersistentFlags().Int("step", 0, "maximum migration steps")
 	migrateCmd.PersistentFlags().String("direction", "up", "migration direction")
+	migrateCmd.PersistentFlags().Bool("dry-run", false, "print the migration SQL without executing it")
+	migrateCmd.PersistentFlags().Bool("skip-checksum", false, "skip detection of applied migrations modified after they ran")
+	migrateCmd.PersistentFlags().Bool("accept-checksums", false, "record the files on disk as the checksum of applied migrations which have none")
+
+	migrateCmd.AddCommand(migrateStatusCmd)
+	migrateCmd.AddCommand(migrateRedoCmd)
 	RootCmd.AddCommand(migrateCmd)
+
+	migrate.SetTable(database.MigrationTable)
 }
 
 func processMigration(cmd *cobra.Command, args []string) {
@@ -34,38 +65,189 @@ func processMigration(cmd *cobra.Command, args []string) {
 		log.WithField("stepStr", stepStr).Fatal("Failed to parse step to int: ", err)
 	}
 
+	dryRun := utils.AnyToBool(cmd.Flag("dry-run").Value.String())
+	skipChecksum := utils.AnyToBool(cmd.Flag("skip-checksum").Value.String())
+	acceptChecksums := utils.AnyToBool(cmd.Flag("accept-checksums").Value.String())
+
 	database.InitializePostgresConnection()
-	migration(direction, step)
+	if dryRun {
+		printPlannedMigrations(migrationDB(), database.MigrationSource(), toMigrationDirection(direction), step)
+		return
+	}
+
+	err = guardedMigration(skipChecksum, acceptChecksums, func(db *sql.DB, unrecorded []string) error {
+		return migration(db, direction, step, unrecorded)
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
 }
 
-func migration(direction string, step int) {
-	var (
-		n                  int
-		migrationDirection migrate.MigrationDirection = migrate.Up
-	)
+func processMigrationStatus(cmd *cobra.Command, args []string) {
+	database.InitializePostgresConnection()
 
-	migrations := &migrate.FileMigrationSource{
-		Dir: "internal/database/migrations/",
+	migrations, err := database.MigrationSource().FindMigrations()
+	if err != nil {
+		log.Fatal("Failed to find migrations: ", err)
 	}
 
-	migrate.SetTable("migrations")
+	records, err := migrate.GetMigrationRecords(migrationDB(), database.MigrationDialect)
+	if err != nil {
+		log.Fatal("Failed to get migration records: ", err)
+	}
 
-	postgresDB, err := database.PostgreSQL.DB()
+	appliedAt := make(map[string]string, len(records))
+	for _, record := range records {
+		appliedAt[record.Id] = record.AppliedAt.Format(time.DateTime)
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "MIGRATION\tAPPLIED AT")
+	for _, m := range migrations {
+		status, ok := appliedAt[m.Id]
+		if !ok {
+			status = "pending"
+		}
+
+		_, _ = fmt.Fprintf(writer, "%s\t%s\n", m.Id, status)
+	}
+
+	utils.WrapCloser(writer.Flush)
+}
+
+func processMigrationRedo(cmd *cobra.Command, args []string) {
+	log.Info("Process redo migration!")
+
+	dryRun := utils.AnyToBool(cmd.Flag("dry-run").Value.String())
+	skipChecksum := utils.AnyToBool(cmd.Flag("skip-checksum").Value.String())
+	acceptChecksums := utils.AnyToBool(cmd.Flag("accept-checksums").Value.String())
+
+	database.InitializePostgresConnection()
+
+	// without applied migration "down 1" does nothing and "up 1" would apply a pending one
+	records, err := migrate.GetMigrationRecords(migrationDB(), database.MigrationDialect)
 	if err != nil {
-		log.WithField("DatabaseDSN", config.DatabaseDSN()).Fatal("Failed to connect database: ", err)
+		log.Fatal("Failed to get migration records: ", err)
 	}
 
-	if direction == "down" {
-		migrationDirection = migrate.Down
+	if len(records) == 0 {
+		log.Fatal("Failed to redo migration: ", ErrNothingToRedo)
+	}
+
+	if !dryRun {
+		err := guardedMigration(skipChecksum, acceptChecksums, func(db *sql.DB, unrecorded []string) error {
+			if err := migration(db, "down", 1, unrecorded); err != nil {
+				return err
+			}
+
+			return migration(db, "up", 1, unrecorded)
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
 	}
 
-	n, err = migrate.ExecMax(postgresDB, "postgres", migrations, migrationDirection, step)
+	// the down migration is not executed on dry run, so the up plan would be empty
+	planned, _, err := migrate.PlanMigration(migrationDB(), database.MigrationDialect, database.MigrationSource(), migrate.Down, 1)
 	if err != nil {
-		log.WithFields(log.Fields{
-			"migrations": utils.Dump(migrations),
-			"direction":  direction,
-		}).Fatal("Failed to migrate database: ", err)
+		log.Fatal("Failed to plan migration: ", err)
+	}
+
+	for _, m := range planned {
+		printMigrationQueries("rollback", m.Id, m.Down)
+		printMigrationQueries("apply", m.Id, m.Up)
+	}
+}
+
+// guardedMigration runs fn while holding the migration advisory lock, so concurrent deploys
+// do not migrate at the same time, and refuses to run when an applied migration was modified
+// or has no recorded checksum. fn receives the applied migrations whose checksum must not be recorded.
+// Errors are returned, so the lock is released before the caller exits.
+func guardedMigration(skipChecksum, acceptChecksums bool, fn func(db *sql.DB, unrecorded []string) error) error {
+	postgresDB := migrationDB()
+
+	release, err := database.AcquireMigrationLock(context.Background(), postgresDB, config.DatabaseMigrationLockTimeout())
+	if err != nil {
+		return fmt.Errorf("failed to acquire migration lock within %s: %w", config.DatabaseMigrationLockTimeout(), err)
+	}
+	defer release()
+
+	unrecorded, err := database.UnrecordedMigrations(postgresDB)
+	if err != nil {
+		return fmt.Errorf("failed to find migration checksums: %w", err)
+	}
+
+	if !skipChecksum {
+		if err := database.VerifyMigrationChecksums(postgresDB); err != nil {
+			return fmt.Errorf("refusing to migrate, revert the file or run with --skip-checksum: %w", err)
+		}
+
+		if len(unrecorded) > 0 && !acceptChecksums {
+			return fmt.Errorf("refusing to migrate, review the files and run with --accept-checksums: %w: %s",
+				database.ErrMigrationChecksumMiss, strings.Join(unrecorded, ", "))
+		}
+	}
+
+	if acceptChecksums {
+		unrecorded = nil
+	}
+
+	return fn(postgresDB, unrecorded)
+}
+
+func migration(db *sql.DB, direction string, step int, unrecorded []string) error {
+	n, err := migrate.ExecMax(db, database.MigrationDialect, database.MigrationSource(), toMigrationDirection(direction), step)
+	if err != nil {
+		return fmt.Errorf("failed to migrate database %s: %w", direction, err)
+	}
+
+	if err := database.SyncMigrationChecksums(db, unrecorded); err != nil {
+		log.Error("Failed to record migration checksums: ", err)
 	}
 
 	log.Infof("Applied %d migrations!\n", n)
+	return nil
+}
+
+func toMigrationDirection(direction string) migrate.MigrationDirection {
+	if direction == "down" {
+		return migrate.Down
+	}
+
+	return migrate.Up
+}
+
+func printPlannedMigrations(db *sql.DB, migrations migrate.MigrationSource, direction migrate.MigrationDirection, step int) {
+	planned, _, err := migrate.PlanMigration(db, database.MigrationDialect, migrations, direction, step)
+	if err != nil {
+		log.Fatal("Failed to plan migration: ", err)
+	}
+
+	action := "apply"
+	if direction == migrate.Down {
+		action = "rollback"
+	}
+
+	for _, m := range planned {
+		printMigrationQueries(action, m.Id, m.Queries)
+	}
+
+	log.Infof("Planned %d migrations!\n", len(planned))
+}
+
+func printMigrationQueries(action, id string, queries []string) {
+	fmt.Printf("==> Would %s migration %s\n", action, id)
+	for _, query := range queries {
+		fmt.Println(query)
+	}
+}
+
+func migrationDB() *sql.DB {
+	postgresDB, err := database.PostgreSQL.DB()
+	if err != nil {
+		log.WithField("DatabaseDSN", config.DatabaseDSN()).Fatal("Failed to connect database: ", err)
+	}
+
+	return postgresDB
 }
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/mazharul-islam/internal/database"
+	"github.com/mazharul-islam/internal/entity"
+	"github.com/mazharul-islam/utils"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const kilometersPerDegree = 111.0
+
+var (
+	seedCmd = &cobra.Command{
+		Use:   "seed",
+		Short: "seed fake users",
+		Long:  `This subcommand generate deterministic fake users and insert them into the users table`,
+		Run:   processSeed,
+	}
+
+	defaultSeedInterests = []string{
+		"music", "movies", "travel", "hiking", "cooking", "reading", "photography", "gaming",
+		"football", "badminton", "yoga", "running", "coffee", "art", "dancing", "swimming",
+		"technology", "fashion", "pets", "gardening", "cycling", "karaoke", "anime", "camping",
+	}
+
+	seedFirstNames = []string{
+		"Andi", "Budi", "Citra", "Dewi", "Eka", "Fajar", "Gita", "Hadi", "Indah", "Joko",
+		"Kartika", "Lestari", "Made", "Nadia", "Oki", "Putri", "Rizky", "Sari", "Taufik", "Wulan",
+	}
+
+	seedLastNames = []string{
+		"Pratama", "Saputra", "Wijaya", "Santoso", "Hidayat", "Kusuma", "Nugroho", "Siregar",
+		"Lubis", "Simanjuntak", "Halim", "Gunawan", "Setiawan", "Utami", "Rahmawati", "Purnama",
+	}
+
+	seedGenders = []string{"male", "female"}
+)
+
+type seedOptions struct {
+	Count     int
+	Seed      int64
+	Latitude  float64
+	Longitude float64
+	RadiusKm  float64
+	Interests []string
+	BatchSize int
+}
+
+func init() {
+	seedCmd.Flags().Int("count", 1000, "number of users to generate")
+	seedCmd.Flags().Int64("seed", 1, "random seed, the same seed generates the same users")
+	seedCmd.Flags().Float64("lat", -6.2088, "latitude of the center of generated locations")
+	seedCmd.Flags().Float64("lng", 106.8456, "longitude of the center of generated locations")
+	seedCmd.Flags().Float64("radius", 50, "radius in kilometers around the center")
+	seedCmd.Flags().StringSlice("interests", defaultSeedInterests, "interest vocabulary")
+	seedCmd.Flags().Int("batch-size", 500, "number of users inserted per batch")
+	RootCmd.AddCommand(seedCmd)
+}
+
+func processSeed(cmd *cobra.Command, args []string) {
+	log.Info("Process seed!")
+
+	var (
+		opts seedOptions
+		err  error
+	)
+
+	flags := cmd.Flags()
+	opts.Count, err = flags.GetInt("count")
+	continueOrFatal(err)
+	opts.Seed, err = flags.GetInt64("seed")
+	continueOrFatal(err)
+	opts.Latitude, err = flags.GetFloat64("lat")
+	continueOrFatal(err)
+	opts.Longitude, err = flags.GetFloat64("lng")
+	continueOrFatal(err)
+	opts.RadiusKm, err = flags.GetFloat64("radius")
+	continueOrFatal(err)
+	opts.Interests, err = flags.GetStringSlice("interests")
+	continueOrFatal(err)
+	opts.BatchSize, err = flags.GetInt("batch-size")
+	continueOrFatal(err)
+
+	if len(opts.Interests) == 0 {
+		log.Fatal("interest vocabulary must not be empty")
+	}
+
+	db, err := database.InitializePostgresConnection()
+	continueOrFatal(err)
+
+	users := generateSeedUsers(opts)
+	if err := db.CreateInBatches(users, utils.ValueOrDefault(opts.BatchSize, 500)).Error; err != nil {
+		log.WithField("seed", opts.Seed).Fatal("Failed to seed users: ", err)
+	}
+
+	log.Infof("Seeded %d users!\n", len(users))
+}
+
+func generateSeedUsers(opts seedOptions) []entity.UserRecord {
+	random := rand.New(rand.NewSource(opts.Seed))
+	users := make([]entity.UserRecord, 0, opts.Count)
+
+	for i := 0; i < opts.Count; i++ {
+		age := 18 + random.Intn(43)
+		minAge := max(18, age-5-random.Intn(5))
+		maxAge := age + 5 + random.Intn(10)
+
+		preferences := entity.Preferences{
+			MaxDistanceKm:     5 + random.Intn(96),
+			PreferredGender:   seedGenders[random.Intn(len(seedGenders))],
+			PreferredAgeRange: []int{minAge, maxAge},
+		}
+
+		users = append(users, entity.UserRecord{
+			Name:        seedFirstNames[random.Intn(len(seedFirstNames))] + " " + seedLastNames[random.Intn(len(seedLastNames))],
+			Age:         uint(age),
+			Gender:      utils.TypeToPointerType(seedGenders[random.Intn(len(seedGenders))]),
+			Location:    utils.TypeToPointerType(randomPointAround(random, opts.Latitude, opts.Longitude, opts.RadiusKm)),
+			Interests:   utils.ToPostgresTextArray(pickInterests(random, opts.Interests)),
+			Preferences: utils.TypeToPointerType(utils.Dump(preferences)),
+		})
+	}
+
+	return users
+}
+
+// randomPointAround returns a uniformly distributed postgres point (longitude, latitude) inside the radius
+func randomPointAround(random *rand.Rand, latitude, longitude, radiusKm float64) string {
+	distance := radiusKm * math.Sqrt(random.Float64())
+	bearing := 2 * math.Pi * random.Float64()
+
+	lat := latitude + distance*math.Cos(bearing)/kilometersPerDegree
+	lng := longitude + distance*math.Sin(bearing)/(kilometersPerDegree*math.Cos(latitude*math.Pi/180))
+
+	return utils.WriteStringTemplate("(%f,%f)", lng, lat)
+}
+
+func pickInterests(random *rand.Rand, vocabulary []string) []string {
+	count := 3 + random.Intn(4)
+	if count > len(vocabulary) {
+		count = len(vocabulary)
+	}
+
+	picked := make([]string, 0, count)
+	for _, idx := range random.Perm(len(vocabulary))[:count] {
+		picked = append(picked, vocabulary[idx])
+	}
+
+	return picked
+}
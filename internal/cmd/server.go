@@ -3,7 +3,6 @@ package cmd
 import (
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
-	"github.com/mazharul-islam/cacher"
 	"github.com/mazharul-islam/config"
 	"github.com/mazharul-islam/docs"
 	"github.com/mazharul-islam/internal/controller/http"
@@ -40,17 +39,8 @@ func server(cmd *cobra.Command, args []string) {
 	postgresDB, err := database.PostgreSQL.DB()
 	defer utils.WrapCloser(postgresDB.Close)
 
-	cacheManager := cacher.ConstructCacheManager()
-
-	if config.EnableCaching() {
-		redisDB, err := database.InitializeRedigoRedisConnectionPool(config.RedisCacheHost(), redisOptions)
-		continueOrFatal(err)
-		defer utils.WrapCloser(redisDB.Close)
-
-		cacheManager.SetConnectionPool(redisDB)
-	}
-
-	cacheManager.SetDisableCaching(!config.EnableCaching())
+	cacheManager, closeCache := InitCacheManager()
+	defer closeCache()
 
 	app := gin.Default()
 
@@ -67,11 +57,16 @@ func server(cmd *cobra.Command, args []string) {
 
 	app.Use(cors.New(corsConfig))
 
+	rateLimiter, closeRateLimiter := InitRateLimiter()
+	defer closeRateLimiter()
+
 	matchService := InitMatchService(db, cacheManager)
 
 	http.RouteService(
 		&app.RouterGroup,
 		matchService,
+		cacheManager,
+		rateLimiter,
 	)
 
 	initSwaggerDocs(&app.RouterGroup)
@@ -0,0 +1,276 @@
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/mazharul-islam/config"
+	"github.com/mazharul-islam/internal/entity"
+	"github.com/mazharul-islam/utils"
+	"github.com/mazharul-islam/utils/httpresponse"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+func (r *Router) initAdminURLRoutes(app *gin.RouterGroup) {
+	admin := app.Group("admin", gin.BasicAuth(gin.Accounts{config.BasicAuthUsername(): config.BasicAuthPassword()}))
+	{
+		admin.GET("/cache/stats", r.GetCacheStats)
+		admin.GET("/cache/keys", r.GetCacheKeys)
+		admin.GET("/cache/envelope", r.GetCacheEnvelope)
+		admin.POST("/cache/purge", r.PurgeCache)
+		admin.GET("/cache/purge/:id", r.GetPurgeCacheJob)
+		admin.DELETE("/cache/purge/:id", r.CancelPurgeCacheJob)
+		admin.GET("/cache/namespaces", r.GetCacheNamespaces)
+		admin.POST("/cache/namespaces/:namespace/bump", r.BumpCacheNamespace)
+	}
+}
+
+// Endpoint Get Cache Stats
+//
+//	@Summary	Endpoint for get cache hit, miss, nil hit, lock wait, wait too long and lost lock counters by key prefix
+//	@Description
+//	@Tags		admin
+//	@Produce	json
+//	@Success	200	{object}	entity.SwaggerResponseOKDTO{data=map[string]cacher.PrefixStats{}}
+//	@Failure	401	{object}	entity.SwaggerResponseUnauthorizedDTO{}	"*Notes: Code data will be return null"
+//	@Router		/admin/cache/stats [get]
+func (r *Router) GetCacheStats(c *gin.Context) {
+	httpresponse.NewHttpResponse().
+		WithData(r.cacheManager.Stats()).
+		WithMessage(successResponse["GetCacheStats"]).
+		ToWrapperResponseDTO(c, http.StatusOK)
+}
+
+// Endpoint Get Cache Keys
+//
+//	@Summary	Endpoint for get a sample of the cache keys matching a prefix, with their TTL and size
+//	@Description
+//	@Tags		admin
+//	@Produce	json
+//	@Param		request	query		entity.RequestSampleCacheKeys	true	"Query Params"
+//	@Success	200		{object}	entity.SwaggerResponseOKDTO{data=[]cacher.KeyInfo{}}
+//	@Failure	400		{object}	entity.SwaggerResponseBadRequestDTO{}			"*Notes: Code data will be return null"
+//	@Failure	401		{object}	entity.SwaggerResponseUnauthorizedDTO{}			"*Notes: Code data will be return null"
+//	@Failure	500		{object}	entity.SwaggerResponseInternalServerErrorDTO{}	"*Notes: Code data will be return null"
+//	@Router		/admin/cache/keys [get]
+func (r *Router) GetCacheKeys(c *gin.Context) {
+	logger := logrus.WithContext(c).WithFields(logrus.Fields{
+		"context": utils.DumpIncomingContext(c),
+	})
+
+	var request entity.RequestSampleCacheKeys
+	if err := c.ShouldBindQuery(&request); err != nil {
+		logger.Error(err)
+		httpErrorHandler(c, err)
+		return
+	}
+
+	request.SetDefaultValue()
+
+	keys, err := r.cacheManager.SampleKeys(c, request.Prefix, request.Count)
+	if err != nil {
+		logger.Error(err)
+		httpErrorHandler(c, err)
+		return
+	}
+
+	httpresponse.NewHttpResponse().
+		WithData(keys).
+		WithMessage(successResponse["GetCacheKeys"]).
+		ToWrapperResponseDTO(c, http.StatusOK)
+}
+
+// Endpoint Get Cache Envelope
+//
+//	@Summary	Endpoint for get the version, creation time and soft TTL of a cached value, data is null for values stored without version
+//	@Description
+//	@Tags		admin
+//	@Produce	json
+//	@Param		request	query		entity.RequestGetCacheEnvelope	true	"Query Params"
+//	@Success	200		{object}	entity.SwaggerResponseOKDTO{data=cacher.EnvelopeMeta{}}
+//	@Failure	400		{object}	entity.SwaggerResponseBadRequestDTO{}			"*Notes: Code data will be return null"
+//	@Failure	401		{object}	entity.SwaggerResponseUnauthorizedDTO{}			"*Notes: Code data will be return null"
+//	@Failure	404		{object}	entity.SwaggerResponseNotFoundDTO{}				"*Notes: Code data will be return null"
+//	@Failure	500		{object}	entity.SwaggerResponseInternalServerErrorDTO{}	"*Notes: Code data will be return null"
+//	@Router		/admin/cache/envelope [get]
+func (r *Router) GetCacheEnvelope(c *gin.Context) {
+	logger := logrus.WithContext(c).WithFields(logrus.Fields{
+		"context": utils.DumpIncomingContext(c),
+	})
+
+	var request entity.RequestGetCacheEnvelope
+	if err := c.ShouldBindQuery(&request); err != nil {
+		logger.Error(err)
+		httpErrorHandler(c, err)
+		return
+	}
+
+	envelope, err := r.cacheManager.GetEnvelope(c, request.Key)
+	if err != nil {
+		logger.WithField("cacheKey", request.Key).Error(err)
+		httpErrorHandler(c, err)
+		return
+	}
+
+	httpresponse.NewHttpResponse().
+		WithData(envelope).
+		WithMessage(successResponse["GetCacheEnvelope"]).
+		ToWrapperResponseDTO(c, http.StatusOK)
+}
+
+// Endpoint Purge Cache
+//
+//	@Summary	Endpoint for delete the cache keys matching a pattern, in background when async
+//	@Description
+//	@Tags		admin
+//	@Accept		json
+//	@Produce	json
+//	@Param		request	body		entity.RequestPurgeCache	true	"Request Body"
+//	@Success	200		{object}	entity.SwaggerResponseOKDTO{data=entity.ResponsePurgeCache{}}
+//	@Success	202		{object}	entity.SwaggerResponseOKDTO{data=cacher.PurgeJob{}}
+//	@Failure	400		{object}	entity.SwaggerResponseBadRequestDTO{}			"*Notes: Code data will be return null"
+//	@Failure	401		{object}	entity.SwaggerResponseUnauthorizedDTO{}			"*Notes: Code data will be return null"
+//	@Failure	500		{object}	entity.SwaggerResponseInternalServerErrorDTO{}	"*Notes: Code data will be return null"
+//	@Router		/admin/cache/purge [post]
+func (r *Router) PurgeCache(c *gin.Context) {
+	logger := logrus.WithContext(c).WithFields(logrus.Fields{
+		"context": utils.DumpIncomingContext(c),
+	})
+
+	var request entity.RequestPurgeCache
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logger.Error(err)
+		httpErrorHandler(c, err)
+		return
+	}
+
+	if request.Async {
+		job, err := r.cacheManager.PurgeAsync(c, request.Pattern)
+		if err != nil {
+			logger.Error(err)
+			httpErrorHandler(c, err)
+			return
+		}
+
+		httpresponse.NewHttpResponse().
+			WithData(job).
+			WithMessage(successResponse["PurgeCache"]).
+			ToWrapperResponseDTO(c, http.StatusAccepted)
+		return
+	}
+
+	deleted, err := r.cacheManager.PurgeCtx(c, request.Pattern)
+	if err != nil {
+		logger.WithField("deleted", deleted).Error(err)
+		httpErrorHandler(c, err)
+		return
+	}
+
+	httpresponse.NewHttpResponse().
+		WithData(entity.ResponsePurgeCache{Deleted: deleted}).
+		WithMessage(successResponse["PurgeCache"]).
+		ToWrapperResponseDTO(c, http.StatusOK)
+}
+
+// Endpoint Get Purge Cache Job
+//
+//	@Summary	Endpoint for get the progress of an async cache purge
+//	@Description
+//	@Tags		admin
+//	@Produce	json
+//	@Param		id	path		string	true	"Purge Job Id"
+//	@Success	200	{object}	entity.SwaggerResponseOKDTO{data=cacher.PurgeJob{}}
+//	@Failure	401	{object}	entity.SwaggerResponseUnauthorizedDTO{}			"*Notes: Code data will be return null"
+//	@Failure	404	{object}	entity.SwaggerResponseNotFoundDTO{}				"*Notes: Code data will be return null"
+//	@Failure	500	{object}	entity.SwaggerResponseInternalServerErrorDTO{}	"*Notes: Code data will be return null"
+//	@Router		/admin/cache/purge/{id} [get]
+func (r *Router) GetPurgeCacheJob(c *gin.Context) {
+	logger := logrus.WithContext(c).WithFields(logrus.Fields{
+		"context": utils.DumpIncomingContext(c),
+	})
+
+	job, err := r.cacheManager.GetPurgeJob(c, c.Param("id"))
+	if err != nil {
+		logger.Error(err)
+		httpErrorHandler(c, err)
+		return
+	}
+
+	httpresponse.NewHttpResponse().
+		WithData(job).
+		WithMessage(successResponse["GetPurgeCacheJob"]).
+		ToWrapperResponseDTO(c, http.StatusOK)
+}
+
+// Endpoint Cancel Purge Cache Job
+//
+//	@Summary	Endpoint for stop an async cache purge after its current batch
+//	@Description
+//	@Tags		admin
+//	@Produce	json
+//	@Param		id	path	string	true	"Purge Job Id"
+//	@Success	204	{object}	entity.SwaggerNoContentResponseDTO{}
+//	@Failure	401	{object}	entity.SwaggerResponseUnauthorizedDTO{}			"*Notes: Code data will be return null"
+//	@Failure	404	{object}	entity.SwaggerResponseNotFoundDTO{}				"*Notes: Code data will be return null"
+//	@Failure	409	{object}	entity.SwaggerResponseConflictDTO{}				"*Notes: Code data will be return null"
+//	@Failure	500	{object}	entity.SwaggerResponseInternalServerErrorDTO{}	"*Notes: Code data will be return null"
+//	@Router		/admin/cache/purge/{id} [delete]
+func (r *Router) CancelPurgeCacheJob(c *gin.Context) {
+	logger := logrus.WithContext(c).WithFields(logrus.Fields{
+		"context": utils.DumpIncomingContext(c),
+	})
+
+	if err := r.cacheManager.CancelPurgeJob(c, c.Param("id")); err != nil {
+		logger.Error(err)
+		httpErrorHandler(c, err)
+		return
+	}
+
+	httpresponse.NoContent(c, httpresponse.NewHttpResponse())
+}
+
+// Endpoint Get Cache Namespaces
+//
+//	@Summary	Endpoint for get the current version of every cache namespace
+//	@Description
+//	@Tags		admin
+//	@Produce	json
+//	@Success	200	{object}	entity.SwaggerResponseOKDTO{data=map[string]int64{}}
+//	@Failure	401	{object}	entity.SwaggerResponseUnauthorizedDTO{}	"*Notes: Code data will be return null"
+//	@Router		/admin/cache/namespaces [get]
+func (r *Router) GetCacheNamespaces(c *gin.Context) {
+	httpresponse.NewHttpResponse().
+		WithData(r.cacheManager.NamespaceVersions()).
+		WithMessage(successResponse["GetCacheNamespaces"]).
+		ToWrapperResponseDTO(c, http.StatusOK)
+}
+
+// Endpoint Bump Cache Namespace
+//
+//	@Summary	Endpoint for invalidate every cache key of a namespace by moving it to a new version
+//	@Description
+//	@Tags		admin
+//	@Produce	json
+//	@Param		namespace	path		string	true	"Example: user"
+//	@Success	200			{object}	entity.SwaggerResponseOKDTO{data=map[string]int64{}}
+//	@Failure	401			{object}	entity.SwaggerResponseUnauthorizedDTO{}			"*Notes: Code data will be return null"
+//	@Failure	404			{object}	entity.SwaggerResponseNotFoundDTO{}				"*Notes: Code data will be return null"
+//	@Failure	500			{object}	entity.SwaggerResponseInternalServerErrorDTO{}	"*Notes: Code data will be return null"
+//	@Router		/admin/cache/namespaces/{namespace}/bump [post]
+func (r *Router) BumpCacheNamespace(c *gin.Context) {
+	logger := logrus.WithContext(c).WithFields(logrus.Fields{
+		"context": utils.DumpIncomingContext(c),
+	})
+
+	namespace := c.Param("namespace")
+	version, err := r.cacheManager.BumpNamespaceVersion(c, namespace)
+	if err != nil {
+		logger.Error(err)
+		httpErrorHandler(c, err)
+		return
+	}
+
+	httpresponse.NewHttpResponse().
+		WithData(map[string]int64{namespace: version}).
+		WithMessage(successResponse["BumpCacheNamespace"]).
+		ToWrapperResponseDTO(c, http.StatusOK)
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
+	"github.com/mazharul-islam/cacher"
 	"github.com/mazharul-islam/internal/entity"
 	"github.com/mazharul-islam/internal/service"
 	"github.com/mazharul-islam/utils"
@@ -18,10 +19,21 @@ var (
 		service.ErrNotFound:            httpresponse.NewHTTPError().WithCode(http.StatusNotFound).WithMessage(service.ErrNotFound),
 		service.ErrBadRequest:          httpresponse.NewHTTPError().WithCode(http.StatusBadRequest).WithMessage(service.ErrBadRequest),
 		service.ErrInternalServerError: ErrInternalServerError,
+		cacher.ErrPurgeJobNotFound:     httpresponse.NewHTTPError().WithCode(http.StatusNotFound).WithMessage(cacher.ErrPurgeJobNotFound),
+		cacher.ErrPurgeJobNotRunning:   httpresponse.NewHTTPError().WithCode(http.StatusConflict).WithMessage(cacher.ErrPurgeJobNotRunning),
+		cacher.ErrUnknownNamespace:     httpresponse.NewHTTPError().WithCode(http.StatusNotFound).WithMessage(cacher.ErrUnknownNamespace),
+		cacher.ErrKeyNotExist:          httpresponse.NewHTTPError().WithCode(http.StatusNotFound).WithMessage(cacher.ErrKeyNotExist),
 	}
 
 	successResponse = map[string]string{
-		"GetListCustomers": "Success Get List Customers",
+		"GetListCustomers":   "Success Get List Customers",
+		"GetCacheStats":      "Success Get Cache Stats",
+		"GetCacheKeys":       "Success Get Cache Keys",
+		"GetCacheEnvelope":   "Success Get Cache Envelope",
+		"PurgeCache":         "Success Purge Cache",
+		"GetPurgeCacheJob":   "Success Get Purge Cache Job",
+		"GetCacheNamespaces": "Success Get Cache Namespaces",
+		"BumpCacheNamespace": "Success Bump Cache Namespace",
 	}
 )
 
@@ -2,20 +2,29 @@ package http
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/mazharul-islam/cacher"
 	"github.com/mazharul-islam/internal/entity"
+	"github.com/mazharul-islam/ratelimit"
 	"github.com/mazharul-islam/utils/httpresponse"
 )
 
 type Router struct {
 	matchService entity.IMatchService
+	cacheManager cacher.CacheManager
+	rateLimiter  ratelimit.Limiter
 }
 
+// RouteService registers the routes, the api is not rate limited when rateLimiter is nil
 func RouteService(
 	app *gin.RouterGroup,
 	matchService entity.IMatchService,
+	cacheManager cacher.CacheManager,
+	rateLimiter ratelimit.Limiter,
 ) {
 	router := &Router{
 		matchService: matchService,
+		cacheManager: cacheManager,
+		rateLimiter:  rateLimiter,
 	}
 
 	router.handlers(app)
@@ -28,6 +37,8 @@ func (r *Router) handlers(app *gin.RouterGroup) {
 	{
 		r.initMatchURLRoutes(apiGroupV1)
 	}
+
+	r.initAdminURLRoutes(app)
 }
 
 func ping(c *gin.Context) {
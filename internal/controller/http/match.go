@@ -3,6 +3,7 @@ package http
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/mazharul-islam/internal/entity"
+	"github.com/mazharul-islam/ratelimit"
 	"github.com/mazharul-islam/utils"
 	"github.com/mazharul-islam/utils/httpresponse"
 	"github.com/sirupsen/logrus"
@@ -11,8 +12,14 @@ import (
 
 func (r *Router) initMatchURLRoutes(app *gin.RouterGroup) {
 	customers := app.Group("match")
+	if r.rateLimiter != nil {
+		// swipes are throttled per device, users behind a NAT share their ip
+		customers.Use(ratelimit.MiddlewareWithKey(r.rateLimiter, ratelimit.DeviceKey))
+	}
+
 	{
 		customers.GET("/recommendations/user/:id", r.GetRecomendations)
+		customers.POST("/like/user/:id", r.LikeUser)
 	}
 }
 
@@ -63,3 +70,43 @@ func (r *Router) GetRecomendations(c *gin.Context) {
 		WithMessage(successResponse["GetListCustomers"]).
 		ToWrapperResponseDTO(c, http.StatusOK)
 }
+
+// Endpoint Like User
+//
+//	@Summary	Endpoint for like a user, the liked user moves up in the recommendations
+//	@Description
+//	@Tags		user
+//	@Accept		json
+//	@Produce	json
+//	@Param		Accept			header		string							false	"Example: application/json"
+//	@Param		Content-Type	header		string							false	"Example: application/json"
+//	@Param		Device-Id		header		string							true	"Example: 5d47eb91-bee9-46b8-9104-aea0f40ef1c3"
+//	@Param		Source			header		string							true	"Example: eraspace"
+//	@Param		id				path		string							true	"User Id"
+//	@Param		request			body		entity.RequestLikeUser			true	"Request Body"
+//	@Success	204
+//	@Failure	400				{object}	entity.SwaggerResponseBadRequestDTO{}			"*Notes: Code data will be return null"
+//	@Failure	401				{object}	entity.SwaggerResponseUnauthorizedDTO{}			"*Notes: Code data will be return null"
+//	@Failure	404				{object}	entity.SwaggerResponseNotFoundDTO{}				"*Notes: Code data will be return null"
+//	@Failure	500				{object}	entity.SwaggerResponseInternalServerErrorDTO{}	"*Notes: Code data will be return null"
+//	@Router		/v1/match/like/user/{id}/ [post]
+func (r *Router) LikeUser(c *gin.Context) {
+	logger := logrus.WithContext(c).WithFields(logrus.Fields{
+		"context": utils.DumpIncomingContext(c),
+	})
+
+	var request entity.RequestLikeUser
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logger.Error(err)
+		httpErrorHandler(c, err)
+		return
+	}
+
+	if err := r.matchService.LikeUser(c, utils.ExpectedUint(c.Param("id")), request); err != nil {
+		logger.Error(err)
+		httpErrorHandler(c, err)
+		return
+	}
+
+	httpresponse.NoContent(c, httpresponse.NewHttpResponse())
+}
@@ -0,0 +1,32 @@
+package database
+
+import (
+	"embed"
+
+	migrate "github.com/rubenv/sql-migrate"
+)
+
+const (
+	// MigrationDialect dialect used by sql-migrate
+	MigrationDialect = "postgres"
+
+	// MigrationTable table name used to record applied migrations
+	MigrationTable = "migrations"
+
+	// MigrationFolderPath relative path of the migration files, used when creating a new migration
+	MigrationFolderPath = "internal/database/migrations/"
+)
+
+// migrationFiles holds the migration files compiled into the binary,
+// so the migrate command works regardless of the working directory.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// MigrationSource returns the embedded migration source
+func MigrationSource() migrate.MigrationSource {
+	return &migrate.EmbedFileSystemMigrationSource{
+		FileSystem: migrationFiles,
+		Root:       "migrations",
+	}
+}
@@ -0,0 +1,220 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jpillora/backoff"
+	"github.com/mazharul-islam/utils"
+	migrate "github.com/rubenv/sql-migrate"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	migrationLockKey       = "mazharul-islam:migrations"
+	migrationChecksumTable = "migration_checksums"
+)
+
+var (
+	ErrMigrationLockTimeout   = errors.New("timeout acquiring migration lock")
+	ErrMigrationChecksumDrift = errors.New("applied migration file was modified")
+	ErrMigrationChecksumMiss  = errors.New("applied migration has no recorded checksum")
+)
+
+// AcquireMigrationLock waits until the postgres advisory lock for migrations is held,
+// so only one process migrates at a time. The returned function releases the lock.
+func AcquireMigrationLock(ctx context.Context, db *sql.DB, timeout time.Duration) (release func(), err error) {
+	// advisory locks belong to a session, lock and unlock must use the same connection
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	lockCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	b := &backoff.Backoff{
+		Min:    100 * time.Millisecond,
+		Max:    2 * time.Second,
+		Jitter: true,
+	}
+
+	for {
+		var locked bool
+		err = conn.QueryRowContext(lockCtx, "SELECT pg_try_advisory_lock(hashtext($1))", migrationLockKey).Scan(&locked)
+		if err != nil && lockCtx.Err() == nil {
+			utils.WrapCloser(conn.Close)
+			return nil, err
+		}
+
+		if locked {
+			break
+		}
+
+		log.Info("waiting for another process to finish migration")
+
+		select {
+		case <-lockCtx.Done():
+			utils.WrapCloser(conn.Close)
+			return nil, ErrMigrationLockTimeout
+		case <-time.After(b.Duration()):
+		}
+	}
+
+	return func() {
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock(hashtext($1))", migrationLockKey); err != nil {
+			log.Error(err)
+		}
+
+		utils.WrapCloser(conn.Close)
+	}, nil
+}
+
+// VerifyMigrationChecksums compares the checksum recorded when each migration was applied
+// with the embedded file, and returns ErrMigrationChecksumDrift when they differ.
+func VerifyMigrationChecksums(db *sql.DB) error {
+	recorded, err := findMigrationChecksums(db)
+	if err != nil {
+		return err
+	}
+
+	var drifted []string
+	for id, checksum := range recorded {
+		current, err := migrationChecksum(id)
+		if err != nil {
+			// the file is gone, sql-migrate reports unknown migrations by itself
+			continue
+		}
+
+		if current != checksum {
+			drifted = append(drifted, id)
+		}
+	}
+
+	if len(drifted) > 0 {
+		return fmt.Errorf("%w: %s", ErrMigrationChecksumDrift, strings.Join(drifted, ", "))
+	}
+
+	return nil
+}
+
+// UnrecordedMigrations returns the applied migrations without recorded checksum, e.g. the ones applied
+// by a process which did not record checksums. Their files may already have drifted, so they are not
+// trusted blindly. On the first guarded run no checksum was ever recorded, the files of the applied
+// migrations are then recorded as their baseline and nothing is returned.
+func UnrecordedMigrations(db *sql.DB) ([]string, error) {
+	records, err := migrate.GetMigrationRecords(db, MigrationDialect)
+	if err != nil {
+		return nil, err
+	}
+
+	recorded, err := findMigrationChecksums(db)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(recorded) == 0 && len(records) > 0 {
+		log.WithField("migrations", len(records)).Warn("recording the checksums of the applied migrations as their baseline")
+		return nil, SyncMigrationChecksums(db, nil)
+	}
+
+	var unrecorded []string
+	for _, record := range records {
+		if _, ok := recorded[record.Id]; !ok {
+			unrecorded = append(unrecorded, record.Id)
+		}
+	}
+
+	return unrecorded, nil
+}
+
+// SyncMigrationChecksums records the checksum of newly applied migrations
+// and forgets the ones that have been rolled back. The ignored migrations are
+// not recorded, so files which were never verified are not accepted silently.
+func SyncMigrationChecksums(db *sql.DB, ignored []string) error {
+	records, err := migrate.GetMigrationRecords(db, MigrationDialect)
+	if err != nil {
+		return err
+	}
+
+	recorded, err := findMigrationChecksums(db)
+	if err != nil {
+		return err
+	}
+
+	applied := make(map[string]bool, len(records))
+	for _, record := range records {
+		applied[record.Id] = true
+		if _, ok := recorded[record.Id]; ok || utils.Contains(ignored, record.Id) {
+			continue
+		}
+
+		checksum, err := migrationChecksum(record.Id)
+		if err != nil {
+			return err
+		}
+
+		query := utils.WriteStringTemplate("INSERT INTO %s (id, checksum) VALUES ($1, $2) ON CONFLICT (id) DO NOTHING", migrationChecksumTable)
+		if _, err := db.Exec(query, record.Id, checksum); err != nil {
+			return err
+		}
+	}
+
+	for id := range recorded {
+		if applied[id] {
+			continue
+		}
+
+		query := utils.WriteStringTemplate("DELETE FROM %s WHERE id = $1", migrationChecksumTable)
+		if _, err := db.Exec(query, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func findMigrationChecksums(db *sql.DB) (map[string]string, error) {
+	query := utils.WriteStringTemplate(`CREATE TABLE IF NOT EXISTS %s (
+		id VARCHAR(255) PRIMARY KEY,
+		checksum VARCHAR(64) NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`, migrationChecksumTable)
+	if _, err := db.Exec(query); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(utils.WriteStringTemplate("SELECT id, checksum FROM %s", migrationChecksumTable))
+	if err != nil {
+		return nil, err
+	}
+	defer utils.WrapCloser(rows.Close)
+
+	checksums := make(map[string]string)
+	for rows.Next() {
+		var id, checksum string
+		if err := rows.Scan(&id, &checksum); err != nil {
+			return nil, err
+		}
+
+		checksums[id] = checksum
+	}
+
+	return checksums, rows.Err()
+}
+
+func migrationChecksum(id string) (string, error) {
+	content, err := migrationFiles.ReadFile("migrations/" + id)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,8 @@
+-- +migrate Up notransaction
+ALTER TABLE users ADD COLUMN IF NOT EXISTS external_id varchar(255) DEFAULT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS "users_external_id_idx" ON "users" ("external_id");
+-- +migrate Down
+DROP INDEX IF EXISTS "users_external_id_idx";
+
+ALTER TABLE users DROP COLUMN IF EXISTS external_id;
@@ -0,0 +1,9 @@
+-- +migrate Up notransaction
+CREATE TABLE IF NOT EXISTS user_likes (
+    liker_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    target_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (liker_id, target_id)
+);
+-- +migrate Down
+DROP TABLE IF EXISTS user_likes;
@@ -4,13 +4,14 @@ import (
 	"github.com/go-redis/redis/v8"
 	redigo "github.com/gomodule/redigo/redis"
 	log "github.com/sirupsen/logrus"
+	"strings"
 	"time"
 )
 
 // RedisConnectionPoolOptions options for the redis connection
 type RedisConnectionPoolOptions struct {
 	// Dial timeout for establishing new connections.
-	// Default is 5 seconds. Only for go-redis.
+	// Default is 5 seconds. Only for go-redis, sentinel and cluster.
 	DialTimeout time.Duration
 
 	// Enables read-only commands on slave nodes.
@@ -18,12 +19,12 @@ type RedisConnectionPoolOptions struct {
 
 	// Timeout for socket reads. If reached, commands will fail
 	// with a timeout instead of blocking. Use value -1 for no timeout and 0 for default.
-	// Default is 3 seconds. Only for go-redis.
+	// Default is 3 seconds. Only for go-redis, sentinel and cluster.
 	ReadTimeout time.Duration
 
 	// Timeout for socket writes. If reached, commands will fail
 	// with a timeout instead of blocking.
-	// Default is ReadTimeout. Only for go-redis.
+	// Default is ReadTimeout. Only for go-redis, sentinel and cluster.
 	WriteTimeout time.Duration
 
 	// Number of idle connections in the pool.
@@ -53,32 +54,76 @@ var defaultRedisConnectionPoolOptions = &RedisConnectionPoolOptions{
 	ReadTimeout:     2 * time.Second,
 }
 
-// InitializeRedigoRedisConnectionPool uses redigo library to establish the redis connection pool
+// InitializeRedigoRedisConnectionPool uses redigo library to establish the redis connection pool.
+// Besides standalone urls, it accepts redis-sentinel://[:password@]host:port[,host:port]/master_name[/db]
+// and redis-cluster://[:password@]host:port[,host:port]
 func InitializeRedigoRedisConnectionPool(url string, opt *RedisConnectionPoolOptions) (*redigo.Pool, error) {
+	options := applyRedisConnectionPoolOptions(opt)
+
+	switch {
+	case strings.HasPrefix(url, redisSentinelScheme+"://"):
+		return initializeRedigoSentinelConnectionPool(url, options)
+	case strings.HasPrefix(url, redisClusterScheme+"://"):
+		return initializeRedigoClusterConnectionPool(url, options)
+	}
+
 	if !isValidRedisStandaloneURL(url) {
 		log.Fatal("invalid redis url :", url)
 	}
 
-	options := applyRedisConnectionPoolOptions(opt)
+	return newRedigoPool(options, func() (redigo.Conn, error) {
+		c, err := redigo.DialURL(url)
+		if err != nil {
+			return nil, err
+		}
+		return c, err
+	}, pingOnBorrow), nil
+}
+
+// InitializeRedigoSubscriberConnectionPool establishes the connection pool of the pub/sub subscribers. Its connections
+// have no read timeout since subscribers wait for messages, they ping the server to detect dead connections instead.
+// In cluster mode the connections go to a single node, messages published on any node reach every node.
+func InitializeRedigoSubscriberConnectionPool(url string, opt *RedisConnectionPoolOptions) (*redigo.Pool, error) {
+	options := *applyRedisConnectionPoolOptions(opt)
+	options.ReadTimeout = 0
+
+	if strings.HasPrefix(url, redisClusterScheme+"://") {
+		return initializeRedigoClusterSubscriberConnectionPool(url, &options)
+	}
+
+	return InitializeRedigoRedisConnectionPool(url, &options)
+}
 
+func newRedigoPool(options *RedisConnectionPoolOptions, dial func() (redigo.Conn, error), testOnBorrow func(redigo.Conn, time.Time) error) *redigo.Pool {
 	return &redigo.Pool{
-		MaxIdle:     options.IdleCount,
-		MaxActive:   options.PoolSize,
-		IdleTimeout: options.IdleTimeout,
-		Dial: func() (redigo.Conn, error) {
-			c, err := redigo.DialURL(url)
-			if err != nil {
-				return nil, err
-			}
-			return c, err
-		},
+		MaxIdle:         options.IdleCount,
+		MaxActive:       options.PoolSize,
+		IdleTimeout:     options.IdleTimeout,
+		Dial:            dial,
 		MaxConnLifetime: options.MaxConnLifetime,
-		TestOnBorrow: func(c redigo.Conn, t time.Time) error {
-			_, err := c.Do("PING")
-			return err
-		},
-		Wait: true, // wait for connection available when maxActive is reached
-	}, nil
+		TestOnBorrow:    testOnBorrow,
+		Wait:            true, // wait for connection available when maxActive is reached
+	}
+}
+
+func pingOnBorrow(c redigo.Conn, _ time.Time) error {
+	_, err := c.Do("PING")
+	return err
+}
+
+// dialOptions returns the redigo dial options of the sentinel and cluster modes.
+func (options *RedisConnectionPoolOptions) dialOptions(password string) []redigo.DialOption {
+	dialOptions := []redigo.DialOption{
+		redigo.DialConnectTimeout(options.DialTimeout),
+		redigo.DialReadTimeout(options.ReadTimeout),
+		redigo.DialWriteTimeout(options.WriteTimeout),
+	}
+
+	if password != "" {
+		dialOptions = append(dialOptions, redigo.DialPassword(password))
+	}
+
+	return dialOptions
 }
 
 func isValidRedisStandaloneURL(url string) bool {
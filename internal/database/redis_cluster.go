@@ -0,0 +1,808 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	redigo "github.com/gomodule/redigo/redis"
+	"github.com/mazharul-islam/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	redisClusterSlots        = 16384
+	redisClusterMaxRedirects = 3
+)
+
+var (
+	ErrRedisClusterNoNode    = errors.New("redis cluster has no reachable node")
+	ErrRedisClusterSendExec  = errors.New("EXEC must be called with Do in redis cluster mode")
+	ErrRedisClusterCrossSlot = errors.New("keys of a transaction must hash to the same slot in redis cluster mode")
+)
+
+type (
+	// redisCluster keeps the slot to node mapping shared by every connection of a cluster pool.
+	redisCluster struct {
+		mu         sync.RWMutex
+		seeds      []string
+		slots      []string
+		options    []redigo.DialOption
+		refreshing atomic.Bool
+	}
+
+	// redisClusterConn routes every command to the node owning the slot of its key, following MOVED and ASK
+	// redirections. The keys of a transaction must hash to the same slot, e.g. by sharing a hash tag like
+	// {user:1} and {user:1}:meta, other transactions are rejected with ErrRedisClusterCrossSlot.
+	// SCAN walks the masters one after the other, other commands without key run on a single node.
+	redisClusterConn struct {
+		cluster  *redisCluster
+		conns    map[string]redigo.Conn
+		pending  []redisClusterReply
+		lastAddr string
+
+		inMulti    bool
+		multiSlot  int
+		multiAddrs []string
+		queued     []string
+	}
+
+	// redisClusterReply is a reply the connection still has to read, in the order the commands were sent
+	redisClusterReply struct {
+		addr string
+
+		// discard replies of the commands added by the connection itself, such as the MULTI sent to each node
+		discard bool
+
+		// value of a reply answered without a node, when addr is empty
+		value any
+	}
+)
+
+func initializeRedigoClusterConnectionPool(rawURL string, options *RedisConnectionPoolOptions) (*redigo.Pool, error) {
+	cluster, err := newRedisCluster(rawURL, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return newRedigoPool(options, func() (redigo.Conn, error) {
+		return &redisClusterConn{
+			cluster: cluster,
+			conns:   make(map[string]redigo.Conn),
+		}, nil
+	}, pingOnBorrow), nil
+}
+
+// initializeRedigoClusterSubscriberConnectionPool connects the subscribers to any node of the cluster. Subscribers
+// only receive, they need neither the slot routing nor a specific node.
+func initializeRedigoClusterSubscriberConnectionPool(rawURL string, options *RedisConnectionPoolOptions) (*redigo.Pool, error) {
+	cluster, err := newRedisCluster(rawURL, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return newRedigoPool(options, cluster.dialAnyNode, pingOnBorrow), nil
+}
+
+func newRedisCluster(rawURL string, options *RedisConnectionPoolOptions) (*redisCluster, error) {
+	addrs, password, _, err := parseRedisMultiHostURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	cluster := &redisCluster{
+		seeds:   addrs,
+		options: options.dialOptions(password),
+	}
+
+	if err := cluster.refresh(); err != nil {
+		return nil, err
+	}
+
+	return cluster, nil
+}
+
+// refresh loads the slot mapping from the first node answering CLUSTER SLOTS.
+func (cluster *redisCluster) refresh() error {
+	var lastErr error = ErrRedisClusterNoNode
+	for _, addr := range cluster.nodes() {
+		slots, err := cluster.querySlots(addr)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		cluster.mu.Lock()
+		cluster.slots = slots
+		cluster.mu.Unlock()
+
+		return nil
+	}
+
+	return lastErr
+}
+
+// dialAnyNode connects to the first reachable node.
+func (cluster *redisCluster) dialAnyNode() (redigo.Conn, error) {
+	var lastErr error = ErrRedisClusterNoNode
+	for _, addr := range cluster.nodes() {
+		c, err := redigo.Dial("tcp", addr, cluster.options...)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		return c, nil
+	}
+
+	return nil, lastErr
+}
+
+// refreshInBackground reloads the slot mapping after a redirection, at most one refresh runs at a time.
+func (cluster *redisCluster) refreshInBackground() {
+	if !cluster.refreshing.CompareAndSwap(false, true) {
+		return
+	}
+
+	go func() {
+		defer cluster.refreshing.Store(false)
+
+		if err := cluster.refresh(); err != nil {
+			log.Error(err)
+		}
+	}()
+}
+
+func (cluster *redisCluster) querySlots(addr string) ([]string, error) {
+	c, err := redigo.Dial("tcp", addr, cluster.options...)
+	if err != nil {
+		return nil, err
+	}
+	defer utils.WrapCloser(c.Close)
+
+	ranges, err := redigo.Values(c.Do("CLUSTER", "SLOTS"))
+	if err != nil {
+		return nil, err
+	}
+
+	queriedHost, _, _ := net.SplitHostPort(addr)
+
+	slots := make([]string, redisClusterSlots)
+	for _, slotRange := range ranges {
+		// start, end, [host, port, id], replicas...
+		values, err := redigo.Values(slotRange, nil)
+		if err != nil || len(values) < 3 {
+			continue
+		}
+
+		start, _ := redigo.Int(values[0], nil)
+		end, _ := redigo.Int(values[1], nil)
+
+		master, err := redigo.Values(values[2], nil)
+		if err != nil || len(master) < 2 {
+			continue
+		}
+
+		host, _ := redigo.String(master[0], nil)
+		port, _ := redigo.Int(master[1], nil)
+
+		// an empty host means the node answering the query
+		host = utils.ValueOrDefault[string](host, queriedHost)
+
+		nodeAddr := net.JoinHostPort(host, strconv.Itoa(port))
+		for slot := start; slot <= end && slot < redisClusterSlots; slot++ {
+			slots[slot] = nodeAddr
+		}
+	}
+
+	return slots, nil
+}
+
+// nodes returns the known nodes followed by the seed nodes.
+func (cluster *redisCluster) nodes() []string {
+	cluster.mu.RLock()
+	defer cluster.mu.RUnlock()
+
+	var nodes []string
+	for _, addr := range cluster.slots {
+		if addr != "" && (len(nodes) == 0 || nodes[len(nodes)-1] != addr) {
+			nodes = append(nodes, addr)
+		}
+	}
+
+	return utils.Unique(append(nodes, cluster.seeds...))
+}
+
+// masters returns the nodes owning slots in slot order, or the seed nodes when the slots are unknown.
+func (cluster *redisCluster) masters() []string {
+	cluster.mu.RLock()
+	defer cluster.mu.RUnlock()
+
+	if cluster.slots == nil {
+		return cluster.seeds
+	}
+
+	var masters []string
+	for _, addr := range cluster.slots {
+		if addr != "" && (len(masters) == 0 || masters[len(masters)-1] != addr) {
+			masters = append(masters, addr)
+		}
+	}
+
+	return utils.Unique(masters)
+}
+
+// nodeBySlot returns the node owning the slot, or a random seed when unknown.
+func (cluster *redisCluster) nodeBySlot(slot int) string {
+	cluster.mu.RLock()
+	defer cluster.mu.RUnlock()
+
+	if slot >= 0 && cluster.slots != nil && cluster.slots[slot] != "" {
+		return cluster.slots[slot]
+	}
+
+	return cluster.seeds[rand.Intn(len(cluster.seeds))]
+}
+
+func (cluster *redisCluster) setSlot(slot int, addr string) {
+	cluster.mu.Lock()
+	defer cluster.mu.Unlock()
+
+	if cluster.slots != nil {
+		cluster.slots[slot] = addr
+	}
+}
+
+// Close :nodoc:
+func (conn *redisClusterConn) Close() error {
+	var err error
+	for _, c := range conn.conns {
+		if closeErr := c.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+
+	conn.conns = make(map[string]redigo.Conn)
+	conn.pending = nil
+	return err
+}
+
+// Err :nodoc:
+func (conn *redisClusterConn) Err() error {
+	for _, c := range conn.conns {
+		if err := c.Err(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Do :nodoc:
+func (conn *redisClusterConn) Do(cmd string, args ...any) (any, error) {
+	return conn.DoContext(context.Background(), cmd, args...)
+}
+
+// DoContext :nodoc:
+func (conn *redisClusterConn) DoContext(ctx context.Context, cmd string, args ...any) (any, error) {
+	switch {
+	case cmd == "":
+		return conn.receiveAll(ctx)
+	case conn.inMulti && strings.EqualFold(cmd, "EXEC"):
+		return conn.exec(ctx)
+	case conn.inMulti && strings.EqualFold(cmd, "DISCARD"):
+		return conn.discard(ctx)
+	case !conn.inMulti && len(conn.pending) == 0 && !strings.EqualFold(cmd, "MULTI"):
+		return conn.do(ctx, cmd, args)
+	}
+
+	if err := conn.Send(cmd, args...); err != nil {
+		return nil, err
+	}
+
+	return conn.receiveAll(ctx)
+}
+
+// Send :nodoc:
+func (conn *redisClusterConn) Send(cmd string, args ...any) error {
+	if strings.EqualFold(cmd, "MULTI") {
+		conn.inMulti, conn.multiSlot = true, -1
+		conn.multiAddrs, conn.queued = nil, nil
+		conn.pending = append(conn.pending, redisClusterReply{value: "OK"})
+		return nil
+	}
+
+	if conn.inMulti && strings.EqualFold(cmd, "DISCARD") {
+		return conn.sendDiscard()
+	}
+
+	if conn.inMulti && strings.EqualFold(cmd, "EXEC") {
+		return ErrRedisClusterSendExec
+	}
+
+	key := commandKey(cmd, args)
+	if conn.inMulti && key != "" {
+		if err := conn.checkMultiSlot(cmd, key, args); err != nil {
+			return err
+		}
+	}
+
+	addr := conn.addrByKey(key)
+	c, err := conn.node(addr)
+	if err != nil {
+		return err
+	}
+
+	if conn.inMulti && !utils.Contains(conn.multiAddrs, addr) {
+		if err := c.Send("MULTI"); err != nil {
+			return err
+		}
+
+		conn.multiAddrs = append(conn.multiAddrs, addr)
+		conn.pending = append(conn.pending, redisClusterReply{addr: addr, discard: true})
+	}
+
+	if err := c.Send(cmd, args...); err != nil {
+		return err
+	}
+
+	if conn.inMulti {
+		conn.queued = append(conn.queued, addr)
+	}
+
+	conn.pending = append(conn.pending, redisClusterReply{addr: addr})
+	conn.lastAddr = addr
+	return nil
+}
+
+// Flush :nodoc:
+func (conn *redisClusterConn) Flush() error {
+	for _, c := range conn.conns {
+		if err := c.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Receive :nodoc:
+func (conn *redisClusterConn) Receive() (any, error) {
+	return conn.ReceiveContext(context.Background())
+}
+
+// ReceiveContext :nodoc:
+func (conn *redisClusterConn) ReceiveContext(ctx context.Context) (any, error) {
+	if reply, ok, err := conn.receivePending(ctx); ok {
+		return reply, err
+	}
+
+	// pushed messages, such as pub/sub, come from the last node a command was sent to
+	c, ok := conn.conns[conn.lastAddr]
+	if !ok {
+		return nil, ErrRedisClusterNoNode
+	}
+
+	return redigo.ReceiveContext(c, ctx)
+}
+
+// do runs a single command outside of a transaction, following redirections.
+func (conn *redisClusterConn) do(ctx context.Context, cmd string, args []any) (any, error) {
+	if keys := splittableKeys(cmd, args); keys != nil {
+		return conn.doPerSlot(ctx, cmd, keys)
+	}
+
+	if strings.EqualFold(cmd, "SCAN") && len(args) > 0 {
+		return conn.scan(ctx, args)
+	}
+
+	addr := conn.addrByKey(commandKey(cmd, args))
+	asking := false
+	for redirects := 0; ; redirects++ {
+		c, err := conn.node(addr)
+		if err != nil {
+			return nil, err
+		}
+
+		if asking {
+			if _, err := redigo.DoContext(c, ctx, "ASKING"); err != nil {
+				return nil, err
+			}
+		}
+
+		reply, err := redigo.DoContext(c, ctx, cmd, args...)
+		conn.lastAddr = addr
+
+		redirectAddr, redirectSlot, isAsk := parseRedirection(err)
+		if redirectAddr == "" || redirects >= redisClusterMaxRedirects {
+			return reply, err
+		}
+
+		if !isAsk {
+			conn.cluster.setSlot(redirectSlot, redirectAddr)
+			conn.cluster.refreshInBackground()
+		}
+
+		addr, asking = redirectAddr, isAsk
+	}
+}
+
+// scan runs SCAN on each master in turn. The cursor is prefixed with the index of the scanned master, e.g. 1:42,
+// so keys are only missed or repeated when the masters change during the scan, like SCAN on a resharded node.
+func (conn *redisClusterConn) scan(ctx context.Context, args []any) (any, error) {
+	masters := conn.cluster.masters()
+
+	index, cursor := 0, argToString(args[0])
+	if i := strings.IndexByte(cursor, ':'); i > 0 {
+		index, _ = strconv.Atoi(cursor[:i])
+		cursor = cursor[i+1:]
+	}
+
+	if index >= len(masters) {
+		return []any{[]byte("0"), []any{}}, nil
+	}
+
+	c, err := conn.node(masters[index])
+	if err != nil {
+		return nil, err
+	}
+
+	conn.lastAddr = masters[index]
+	reply, err := redigo.Values(redigo.DoContext(c, ctx, "SCAN", append([]any{cursor}, args[1:]...)...))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(reply) != 2 {
+		return nil, redigo.Error("unexpected SCAN reply")
+	}
+
+	nextCursor, err := redigo.String(reply[0], nil)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case nextCursor != "0":
+		reply[0] = []byte(strconv.Itoa(index) + ":" + nextCursor)
+	case index+1 < len(masters):
+		reply[0] = []byte(strconv.Itoa(index+1) + ":0")
+	}
+
+	return reply, nil
+}
+
+// doPerSlot splits a multi key command whose keys live in several slots, then merges the replies.
+func (conn *redisClusterConn) doPerSlot(ctx context.Context, cmd string, keys []any) (any, error) {
+	var slots []int
+	indexesBySlot := make(map[int][]int)
+	for i, key := range keys {
+		slot := keySlot(argToString(key))
+		if _, ok := indexesBySlot[slot]; !ok {
+			slots = append(slots, slot)
+		}
+
+		indexesBySlot[slot] = append(indexesBySlot[slot], i)
+	}
+
+	isMGet := strings.EqualFold(cmd, "MGET")
+	values := make([]any, len(keys))
+	var total int64
+	for _, slot := range slots {
+		slotKeys := make([]any, 0, len(indexesBySlot[slot]))
+		for _, i := range indexesBySlot[slot] {
+			slotKeys = append(slotKeys, keys[i])
+		}
+
+		reply, err := conn.do(ctx, cmd, slotKeys)
+		if err != nil {
+			return nil, err
+		}
+
+		if !isMGet {
+			count, _ := redigo.Int64(reply, nil)
+			total += count
+			continue
+		}
+
+		slotValues, _ := redigo.Values(reply, nil)
+		for j, i := range indexesBySlot[slot] {
+			if j < len(slotValues) {
+				values[i] = slotValues[j]
+			}
+		}
+	}
+
+	if isMGet {
+		return values, nil
+	}
+
+	return total, nil
+}
+
+// checkMultiSlot makes sure the keys of the command hash to the slot of the transaction. The commands of a
+// transaction spanning several slots could not be committed atomically, the nodes would each run a part of it.
+func (conn *redisClusterConn) checkMultiSlot(cmd, key string, args []any) error {
+	if splittableKeys(cmd, args) != nil {
+		return ErrRedisClusterCrossSlot
+	}
+
+	slot := keySlot(key)
+	if conn.multiSlot >= 0 && conn.multiSlot != slot {
+		return ErrRedisClusterCrossSlot
+	}
+
+	conn.multiSlot = slot
+	return nil
+}
+
+// exec commits the transaction on every node involved, and merges the replies in the order commands were queued.
+func (conn *redisClusterConn) exec(ctx context.Context) (any, error) {
+	addrs, queued := conn.multiAddrs, conn.queued
+	conn.inMulti, conn.multiAddrs, conn.queued = false, nil, nil
+
+	// queue errors make EXEC abort, they are reported by EXEC itself
+	if _, err := conn.receiveAll(ctx); err != nil {
+		if _, isRedisErr := err.(redigo.Error); !isRedisErr {
+			return nil, err
+		}
+	}
+
+	for _, addr := range addrs {
+		if err := conn.conns[addr].Send("EXEC"); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := conn.Flush(); err != nil {
+		return nil, err
+	}
+
+	var execErr error
+	repliesByAddr := make(map[string][]any, len(addrs))
+	for _, addr := range addrs {
+		replies, err := redigo.Values(redigo.ReceiveContext(conn.conns[addr], ctx))
+		if err != nil && execErr == nil {
+			execErr = err
+		}
+
+		repliesByAddr[addr] = replies
+	}
+
+	if execErr != nil {
+		return nil, execErr
+	}
+
+	replies := make([]any, 0, len(queued))
+	for _, addr := range queued {
+		if len(repliesByAddr[addr]) == 0 {
+			return nil, ErrRedisClusterNoNode
+		}
+
+		replies = append(replies, repliesByAddr[addr][0])
+		repliesByAddr[addr] = repliesByAddr[addr][1:]
+	}
+
+	return replies, nil
+}
+
+func (conn *redisClusterConn) discard(ctx context.Context) (any, error) {
+	if err := conn.sendDiscard(); err != nil {
+		return nil, err
+	}
+
+	return conn.receiveAll(ctx)
+}
+
+// sendDiscard rolls back the transaction on every node involved.
+func (conn *redisClusterConn) sendDiscard() error {
+	addrs := conn.multiAddrs
+	conn.inMulti, conn.multiAddrs, conn.queued = false, nil, nil
+
+	if len(addrs) == 0 {
+		conn.pending = append(conn.pending, redisClusterReply{value: "OK"})
+		return nil
+	}
+
+	for i, addr := range addrs {
+		if err := conn.conns[addr].Send("DISCARD"); err != nil {
+			return err
+		}
+
+		// a single reply is returned to the caller, like the DISCARD of a single node
+		conn.pending = append(conn.pending, redisClusterReply{addr: addr, discard: i > 0})
+	}
+
+	return nil
+}
+
+// receiveAll reads every pending reply like redigo does, returning the last reply and the first redis error.
+func (conn *redisClusterConn) receiveAll(ctx context.Context) (reply any, err error) {
+	if err := conn.Flush(); err != nil {
+		return nil, err
+	}
+
+	for {
+		pendingReply, ok, pendingErr := conn.receivePending(ctx)
+		if !ok {
+			return reply, err
+		}
+
+		if pendingErr != nil {
+			if _, isRedisErr := pendingErr.(redigo.Error); !isRedisErr {
+				return nil, pendingErr
+			}
+
+			if err == nil {
+				err = pendingErr
+			}
+		}
+
+		reply = pendingReply
+	}
+}
+
+// receivePending reads the reply of the oldest pending command, ok is false when nothing is pending.
+func (conn *redisClusterConn) receivePending(ctx context.Context) (reply any, ok bool, err error) {
+	for len(conn.pending) > 0 {
+		next := conn.pending[0]
+		conn.pending = conn.pending[1:]
+
+		if next.addr == "" {
+			return next.value, true, nil
+		}
+
+		reply, err := redigo.ReceiveContext(conn.conns[next.addr], ctx)
+		if !next.discard {
+			return reply, true, err
+		}
+
+		if _, isRedisErr := err.(redigo.Error); err != nil && !isRedisErr {
+			return nil, true, err
+		}
+	}
+
+	return nil, false, nil
+}
+
+// addrByKey returns the node of the key, commands without key stay on the node used last.
+func (conn *redisClusterConn) addrByKey(key string) string {
+	if key != "" {
+		return conn.cluster.nodeBySlot(keySlot(key))
+	}
+
+	if conn.inMulti && len(conn.multiAddrs) > 0 {
+		return conn.multiAddrs[0]
+	}
+
+	if conn.lastAddr != "" {
+		return conn.lastAddr
+	}
+
+	return conn.cluster.nodeBySlot(-1)
+}
+
+func (conn *redisClusterConn) node(addr string) (redigo.Conn, error) {
+	if c, ok := conn.conns[addr]; ok {
+		return c, nil
+	}
+
+	c, err := redigo.Dial("tcp", addr, conn.cluster.options...)
+	if err != nil {
+		return nil, err
+	}
+
+	conn.conns[addr] = c
+	return c, nil
+}
+
+// commandKey returns the first key of the command, or an empty string for commands without key.
+func commandKey(cmd string, args []any) string {
+	switch strings.ToUpper(cmd) {
+	case "PING", "ECHO", "INFO", "TIME", "ROLE", "SCAN", "SCRIPT", "CLUSTER", "ASKING", "MULTI", "EXEC", "DISCARD",
+		"PUBLISH", "SUBSCRIBE", "UNSUBSCRIBE", "PSUBSCRIBE", "PUNSUBSCRIBE", "FLUSHDB", "FLUSHALL", "DBSIZE":
+		return ""
+	case "EVAL", "EVALSHA":
+		// script, numkeys, keys...
+		if len(args) > 2 && argToString(args[1]) != "0" {
+			return argToString(args[2])
+		}
+
+		return ""
+	}
+
+	if len(args) == 0 {
+		return ""
+	}
+
+	return argToString(args[0])
+}
+
+// splittableKeys returns the keys of a multi key command spanning several slots, which has to run per slot.
+func splittableKeys(cmd string, args []any) []any {
+	switch strings.ToUpper(cmd) {
+	case "DEL", "UNLINK", "EXISTS", "TOUCH", "MGET":
+	default:
+		return nil
+	}
+
+	if len(args) < 2 {
+		return nil
+	}
+
+	slot := keySlot(argToString(args[0]))
+	for _, key := range args[1:] {
+		if keySlot(argToString(key)) != slot {
+			return args
+		}
+	}
+
+	return nil
+}
+
+// parseRedirection parses MOVED and ASK errors in the form of "MOVED 3999 127.0.0.1:6381".
+func parseRedirection(err error) (addr string, slot int, isAsk bool) {
+	redisErr, ok := err.(redigo.Error)
+	if !ok {
+		return "", 0, false
+	}
+
+	fields := strings.Fields(string(redisErr))
+	if len(fields) != 3 || fields[0] != "MOVED" && fields[0] != "ASK" {
+		return "", 0, false
+	}
+
+	slot, convErr := strconv.Atoi(fields[1])
+	if convErr != nil || slot < 0 || slot >= redisClusterSlots {
+		return "", 0, false
+	}
+
+	return fields[2], slot, fields[0] == "ASK"
+}
+
+// keySlot returns the cluster slot of the key, only the hash tag between the first braces is hashed when present.
+func keySlot(key string) int {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			key = key[start+1 : start+1+end]
+		}
+	}
+
+	return int(crc16(key) % redisClusterSlots)
+}
+
+// crc16 is the CRC16-CCITT (XMODEM) checksum used by redis cluster.
+func crc16(data string) uint16 {
+	var crc uint16
+	for i := 0; i < len(data); i++ {
+		crc ^= uint16(data[i]) << 8
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+
+	return crc
+}
+
+func argToString(arg any) string {
+	switch value := arg.(type) {
+	case string:
+		return value
+	case []byte:
+		return string(value)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+var _ redigo.ConnWithContext = (*redisClusterConn)(nil)
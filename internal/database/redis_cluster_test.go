@@ -0,0 +1,369 @@
+package database
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	redigo "github.com/gomodule/redigo/redis"
+)
+
+// fakeRedisNode answers the RESP commands of a redis cluster node with its handler, MULTI and EXEC are queued
+// per connection like redis does.
+type fakeRedisNode struct {
+	listener net.Listener
+	handle   func(args []string) any
+
+	mu       sync.Mutex
+	commands []string
+}
+
+func newFakeRedisNode(t *testing.T, handle func(args []string) any) *fakeRedisNode {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	node := &fakeRedisNode{listener: listener, handle: handle}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			c, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go node.serve(c)
+		}
+	}()
+
+	return node
+}
+
+func (node *fakeRedisNode) addr() string {
+	return node.listener.Addr().String()
+}
+
+// received returns the commands received by the node, without the CLUSTER SLOTS of the slot discovery.
+func (node *fakeRedisNode) received() []string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	return append([]string(nil), node.commands...)
+}
+
+func (node *fakeRedisNode) serve(c net.Conn) {
+	defer func() { _ = c.Close() }()
+
+	reader := bufio.NewReader(c)
+	var queued [][]string
+	inMulti := false
+	for {
+		args, err := readFakeCommand(reader)
+		if err != nil {
+			return
+		}
+
+		cmd := strings.ToUpper(args[0])
+		if cmd != "CLUSTER" {
+			node.mu.Lock()
+			node.commands = append(node.commands, strings.Join(args, " "))
+			node.mu.Unlock()
+		}
+
+		var reply any
+		switch {
+		case cmd == "MULTI":
+			inMulti, queued, reply = true, nil, "OK"
+		case cmd == "DISCARD":
+			inMulti, queued, reply = false, nil, "OK"
+		case cmd == "EXEC":
+			replies := make([]any, 0, len(queued))
+			for _, queuedArgs := range queued {
+				replies = append(replies, node.handle(queuedArgs))
+			}
+			inMulti, queued, reply = false, nil, replies
+		case inMulti:
+			queued, reply = append(queued, args), "QUEUED"
+		default:
+			reply = node.handle(args)
+		}
+
+		if _, err := c.Write(encodeFakeReply(reply)); err != nil {
+			return
+		}
+	}
+}
+
+func readFakeCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		if _, err := reader.ReadString('\n'); err != nil {
+			return nil, err
+		}
+
+		arg, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		args = append(args, strings.TrimSuffix(arg, "\r\n"))
+	}
+
+	return args, nil
+}
+
+func encodeFakeReply(reply any) []byte {
+	switch value := reply.(type) {
+	case nil:
+		return []byte("$-1\r\n")
+	case redigo.Error:
+		return []byte("-" + string(value) + "\r\n")
+	case string:
+		return []byte("+" + value + "\r\n")
+	case int:
+		return []byte(":" + strconv.Itoa(value) + "\r\n")
+	case []byte:
+		return []byte(fmt.Sprintf("$%d\r\n%s\r\n", len(value), value))
+	case []any:
+		encoded := []byte(fmt.Sprintf("*%d\r\n", len(value)))
+		for _, item := range value {
+			encoded = append(encoded, encodeFakeReply(item)...)
+		}
+		return encoded
+	default:
+		panic(fmt.Sprintf("unsupported reply %T", reply))
+	}
+}
+
+// fakeClusterSlots answers CLUSTER SLOTS with the ranges, given as start, end and node.
+func fakeClusterSlots(ranges ...any) []any {
+	var reply []any
+	for i := 0; i+2 < len(ranges); i += 3 {
+		host, port, _ := net.SplitHostPort(ranges[i+2].(*fakeRedisNode).addr())
+		portNumber, _ := strconv.Atoi(port)
+		reply = append(reply, []any{ranges[i], ranges[i+1], []any{[]byte(host), portNumber}})
+	}
+
+	return reply
+}
+
+func newTestClusterConn(t *testing.T, seed *fakeRedisNode) *redisClusterConn {
+	t.Helper()
+
+	cluster := &redisCluster{seeds: []string{seed.addr()}}
+	if err := cluster.refresh(); err != nil {
+		t.Fatal(err)
+	}
+
+	conn := &redisClusterConn{cluster: cluster, conns: make(map[string]redigo.Conn)}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return conn
+}
+
+func TestKeySlot(t *testing.T) {
+	tests := []struct {
+		key  string
+		slot int
+	}{
+		{key: "123456789", slot: 12739},
+		{key: "foo", slot: 12182},
+		{key: "bar", slot: 5061},
+		{key: "{bar}:meta", slot: 5061},
+		{key: "foo{bar}{zap}", slot: 5061},
+	}
+
+	for _, test := range tests {
+		if slot := keySlot(test.key); slot != test.slot {
+			t.Errorf("keySlot(%q) = %d, want %d", test.key, slot, test.slot)
+		}
+	}
+
+	// an empty hash tag is not a tag, the whole key is hashed
+	if keySlot("foo{}{bar}") == keySlot("bar") {
+		t.Errorf("keySlot(%q) must hash the whole key", "foo{}{bar}")
+	}
+}
+
+func TestRedisClusterConnRoutesBySlot(t *testing.T) {
+	var first, second *fakeRedisNode
+	handle := func(name string) func(args []string) any {
+		return func(args []string) any {
+			if strings.EqualFold(args[0], "CLUSTER") {
+				return fakeClusterSlots(0, 8191, first, 8192, 16383, second)
+			}
+
+			return []byte(name)
+		}
+	}
+	first = newFakeRedisNode(t, handle("first"))
+	second = newFakeRedisNode(t, handle("second"))
+
+	conn := newTestClusterConn(t, first)
+
+	for key, want := range map[string]string{"bar": "first", "foo": "second", "{bar}:meta": "first"} {
+		reply, err := redigo.String(conn.Do("GET", key))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if reply != want {
+			t.Errorf("GET %s ran on %s, want %s", key, reply, want)
+		}
+	}
+}
+
+func TestRedisClusterConnFollowsMoved(t *testing.T) {
+	var first, second *fakeRedisNode
+	first = newFakeRedisNode(t, func(args []string) any {
+		if strings.EqualFold(args[0], "CLUSTER") {
+			return fakeClusterSlots(0, 16383, first)
+		}
+
+		return redigo.Error(fmt.Sprintf("MOVED %d %s", keySlot(args[1]), second.addr()))
+	})
+	second = newFakeRedisNode(t, func(args []string) any {
+		return []byte("moved value")
+	})
+
+	conn := newTestClusterConn(t, first)
+
+	reply, err := redigo.String(conn.Do("GET", "foo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reply != "moved value" {
+		t.Errorf("GET foo = %q, want the value of the node it moved to", reply)
+	}
+
+	if got := second.received(); len(got) != 1 || got[0] != "GET foo" {
+		t.Errorf("moved node received %v, want [GET foo]", got)
+	}
+}
+
+func TestRedisClusterConnFollowsAsk(t *testing.T) {
+	var first, second *fakeRedisNode
+	first = newFakeRedisNode(t, func(args []string) any {
+		if strings.EqualFold(args[0], "CLUSTER") {
+			return fakeClusterSlots(0, 16383, first)
+		}
+
+		return redigo.Error(fmt.Sprintf("ASK %d %s", keySlot(args[1]), second.addr()))
+	})
+	second = newFakeRedisNode(t, func(args []string) any {
+		if strings.EqualFold(args[0], "ASKING") {
+			return "OK"
+		}
+
+		return []byte("migrating value")
+	})
+
+	conn := newTestClusterConn(t, first)
+
+	reply, err := redigo.String(conn.Do("GET", "foo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reply != "migrating value" {
+		t.Errorf("GET foo = %q, want the value of the importing node", reply)
+	}
+
+	if got := second.received(); len(got) != 2 || got[0] != "ASKING" || got[1] != "GET foo" {
+		t.Errorf("importing node received %v, want [ASKING GET foo]", got)
+	}
+
+	// an ASK redirection is for a single command, the slot still belongs to the first node
+	if addr := conn.cluster.nodeBySlot(keySlot("foo")); addr != first.addr() {
+		t.Errorf("slot of foo moved to %s after ASK, want %s", addr, first.addr())
+	}
+}
+
+func TestRedisClusterConnRejectsCrossSlotMulti(t *testing.T) {
+	var node *fakeRedisNode
+	node = newFakeRedisNode(t, func(args []string) any {
+		if strings.EqualFold(args[0], "CLUSTER") {
+			return fakeClusterSlots(0, 16383, node)
+		}
+
+		return "OK"
+	})
+
+	conn := newTestClusterConn(t, node)
+
+	if err := conn.Send("MULTI"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := conn.Send("SET", "foo", "1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// both keys are on the same node, but a resharding could move them apart
+	if err := conn.Send("SET", "bar", "1"); err != ErrRedisClusterCrossSlot {
+		t.Errorf("SET bar in the transaction of foo returned %v, want %v", err, ErrRedisClusterCrossSlot)
+	}
+
+	if _, err := conn.Do("DISCARD"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := conn.Send("MULTI"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := conn.Send("DEL", "foo", "bar"); err != ErrRedisClusterCrossSlot {
+		t.Errorf("DEL foo bar in a transaction returned %v, want %v", err, ErrRedisClusterCrossSlot)
+	}
+
+	if _, err := conn.Do("DISCARD"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRedisClusterConnRunsSameSlotMulti(t *testing.T) {
+	var node *fakeRedisNode
+	node = newFakeRedisNode(t, func(args []string) any {
+		if strings.EqualFold(args[0], "CLUSTER") {
+			return fakeClusterSlots(0, 16383, node)
+		}
+
+		return "OK"
+	})
+
+	conn := newTestClusterConn(t, node)
+
+	for _, args := range [][]any{{"MULTI"}, {"SET", "{user:1}", "1"}, {"SET", "{user:1}:meta", "1"}} {
+		if err := conn.Send(args[0].(string), args[1:]...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	replies, err := redigo.Strings(conn.Do("EXEC"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(replies) != 2 || replies[0] != "OK" || replies[1] != "OK" {
+		t.Errorf("EXEC = %v, want [OK OK]", replies)
+	}
+}
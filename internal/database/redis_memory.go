@@ -0,0 +1,35 @@
+//go:build memoryredis
+
+package database
+
+import (
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// memoryRedisTick is how often the memory redis expires its keys, it does not follow the clock by itself
+const memoryRedisTick = 100 * time.Millisecond
+
+// StartMemoryRedis starts a redis server keeping its data in process memory, to run without redis.
+// It returns the url of the server, the data is lost when the process exits. The server is only built
+// into binaries built with the memoryredis tag.
+func StartMemoryRedis() (string, error) {
+	server := miniredis.NewMiniRedis()
+	if err := server.Start(); err != nil {
+		return "", err
+	}
+
+	go func() {
+		ticker := time.NewTicker(memoryRedisTick)
+		defer ticker.Stop()
+
+		last := time.Now()
+		for now := range ticker.C {
+			server.FastForward(now.Sub(last))
+			last = now
+		}
+	}()
+
+	return "redis://" + server.Addr(), nil
+}
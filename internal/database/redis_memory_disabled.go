@@ -0,0 +1,12 @@
+//go:build !memoryredis
+
+package database
+
+import "errors"
+
+var ErrMemoryRedisNotBuilt = errors.New("memory redis is not built in, build with -tags memoryredis")
+
+// StartMemoryRedis fails, the memory redis is only built into binaries built with the memoryredis tag.
+func StartMemoryRedis() (string, error) {
+	return "", ErrMemoryRedisNotBuilt
+}
@@ -0,0 +1,172 @@
+package database
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	redigo "github.com/gomodule/redigo/redis"
+	"github.com/mazharul-islam/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	redisSentinelScheme = "redis-sentinel"
+	redisClusterScheme  = "redis-cluster"
+)
+
+var (
+	ErrRedisNotMaster             = errors.New("redis node is not a master")
+	ErrRedisSentinelMasterUnknown = errors.New("redis sentinel does not know the master")
+	ErrInvalidRedisURL            = errors.New("invalid redis url")
+)
+
+// redisSentinel discovers the current master of a sentinel managed redis.
+type redisSentinel struct {
+	mu         sync.Mutex
+	addrs      []string
+	masterName string
+	options    []redigo.DialOption
+}
+
+func initializeRedigoSentinelConnectionPool(rawURL string, options *RedisConnectionPoolOptions) (*redigo.Pool, error) {
+	addrs, password, path, err := parseRedisMultiHostURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// path is /master_name or /master_name/db
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if segments[0] == "" || len(segments) > 2 {
+		return nil, ErrInvalidRedisURL
+	}
+
+	masterOptions := options.dialOptions(password)
+	if len(segments) == 2 {
+		db, err := strconv.Atoi(segments[1])
+		if err != nil {
+			return nil, ErrInvalidRedisURL
+		}
+
+		masterOptions = append(masterOptions, redigo.DialDatabase(db))
+	}
+
+	sentinel := &redisSentinel{
+		addrs:      addrs,
+		masterName: segments[0],
+		options:    options.dialOptions(""),
+	}
+
+	// a new master is discovered on dial, connections to a demoted master are dropped on borrow
+	return newRedigoPool(options, func() (redigo.Conn, error) {
+		addr, err := sentinel.masterAddr()
+		if err != nil {
+			return nil, err
+		}
+
+		c, err := redigo.Dial("tcp", addr, masterOptions...)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := checkMasterRole(c); err != nil {
+			utils.WrapCloser(c.Close)
+			return nil, err
+		}
+
+		return c, nil
+	}, func(c redigo.Conn, _ time.Time) error {
+		return checkMasterRole(c)
+	}), nil
+}
+
+// masterAddr asks the sentinels for the address of the master, the first sentinel answering is tried first next time.
+func (sentinel *redisSentinel) masterAddr() (string, error) {
+	sentinel.mu.Lock()
+	defer sentinel.mu.Unlock()
+
+	lastErr := ErrRedisSentinelMasterUnknown
+	for i, addr := range sentinel.addrs {
+		masterAddr, err := sentinel.queryMasterAddr(addr)
+		if err != nil {
+			log.WithField("sentinel", addr).Warn(err)
+			lastErr = err
+			continue
+		}
+
+		copy(sentinel.addrs[1:i+1], sentinel.addrs[:i])
+		sentinel.addrs[0] = addr
+
+		return masterAddr, nil
+	}
+
+	return "", lastErr
+}
+
+func (sentinel *redisSentinel) queryMasterAddr(addr string) (string, error) {
+	c, err := redigo.Dial("tcp", addr, sentinel.options...)
+	if err != nil {
+		return "", err
+	}
+	defer utils.WrapCloser(c.Close)
+
+	reply, err := redigo.Strings(c.Do("SENTINEL", "get-master-addr-by-name", sentinel.masterName))
+	if err == redigo.ErrNil || err == nil && len(reply) != 2 {
+		return "", ErrRedisSentinelMasterUnknown
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return net.JoinHostPort(reply[0], reply[1]), nil
+}
+
+// checkMasterRole makes sure the connection still points to the master, after a failover the old master is demoted.
+func checkMasterRole(c redigo.Conn) error {
+	reply, err := redigo.Values(c.Do("ROLE"))
+	if err != nil {
+		return err
+	}
+
+	if len(reply) == 0 {
+		return ErrRedisNotMaster
+	}
+
+	role, err := redigo.String(reply[0], nil)
+	if err != nil {
+		return err
+	}
+
+	if role != "master" {
+		return ErrRedisNotMaster
+	}
+
+	return nil
+}
+
+// parseRedisMultiHostURL parses urls in the form of scheme://[:password@]host:port[,host:port][/path]
+func parseRedisMultiHostURL(rawURL string) (addrs []string, password, path string, err error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	for _, addr := range strings.Split(parsed.Host, ",") {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			return nil, "", "", ErrInvalidRedisURL
+		}
+
+		addrs = append(addrs, addr)
+	}
+
+	if parsed.User != nil {
+		password, _ = parsed.User.Password()
+	}
+
+	return addrs, password, parsed.Path, nil
+}
@@ -0,0 +1,31 @@
+package entity
+
+const DefaultSampleCacheKeysCount = 100
+
+// RequestSampleCacheKeys query params of the cache keys sampling endpoint
+type RequestSampleCacheKeys struct {
+	Prefix string `form:"prefix" binding:"required"`
+	Count  int    `form:"count" binding:"omitempty,min=1,max=1000"`
+}
+
+func (r *RequestSampleCacheKeys) SetDefaultValue() {
+	if r.Count <= 0 {
+		r.Count = DefaultSampleCacheKeysCount
+	}
+}
+
+// RequestPurgeCache body of the cache purge endpoint, the pattern uses the redis SCAN MATCH syntax
+type RequestPurgeCache struct {
+	Pattern string `json:"pattern" binding:"required"`
+	Async   bool   `json:"async"`
+}
+
+// ResponsePurgeCache result of a purge which is not async
+type ResponsePurgeCache struct {
+	Deleted int64 `json:"deleted"`
+}
+
+// RequestGetCacheEnvelope query params of the cache envelope endpoint
+type RequestGetCacheEnvelope struct {
+	Key string `form:"key" binding:"required"`
+}
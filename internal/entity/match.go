@@ -1,15 +1,35 @@
 package entity
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type (
 	IMatchService interface {
 		GetListRecommendations(c context.Context, id uint, requestFilter RequestFilterUsers) ([]Users, CursorInfo, error)
+		LikeUser(c context.Context, id uint, request RequestLikeUser) error
+	}
+
+	RequestLikeUser struct {
+		TargetUserID uint `json:"target_user_id" binding:"required"`
+	}
+
+	// UserLike maps a row of the user_likes table, a liker likes a target once
+	UserLike struct {
+		LikerID   uint `gorm:"primaryKey"`
+		TargetID  uint `gorm:"primaryKey"`
+		CreatedAt time.Time
 	}
 
 	Preferences struct {
-		MaxDistanceKm     int    `json:"max_distance_km"`
-		PreferredGender   string `json:"preferred_gender"`
-		PreferredAgeRange []int  `json:"preferred_age_range"`
+		MaxDistanceKm     int    `json:"max_distance_km" validate:"gte=0"`
+		PreferredGender   string `json:"preferred_gender" validate:"omitempty,oneof=male female"`
+		PreferredAgeRange []int  `json:"preferred_age_range" validate:"omitempty,len=2,dive,gte=18"`
 	}
 )
+
+// TableName :nodoc:
+func (UserLike) TableName() string {
+	return "user_likes"
+}
@@ -55,3 +55,10 @@ type SwaggerResponseNotFoundDTO struct {
 	Data    any    `json:"data"`
 	Message string `json:"message" example:"Not Found"`
 }
+
+type SwaggerResponseConflictDTO struct {
+	SwaggerBaseResponseDTO
+	//Will return null
+	Data    any    `json:"data"`
+	Message string `json:"message" example:"Conflict"`
+}
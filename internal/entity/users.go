@@ -2,7 +2,10 @@ package entity
 
 import (
 	"context"
+	"github.com/mazharul-islam/utils"
 	"github.com/pilagod/gorm-cursor-paginator/v2/paginator"
+	"net/url"
+	"time"
 )
 
 type (
@@ -14,11 +17,43 @@ type (
 		Location    string
 		Interest    string
 		Preferences string
+		UpdatedAt   time.Time `json:"-"`
+	}
+
+	// UserRecord maps a row of the users table for bulk writes
+	UserRecord struct {
+		ID          uint `gorm:"primaryKey"`
+		ExternalID  *string
+		Name        string
+		Age         uint
+		Gender      *string
+		Location    *string
+		Interests   string
+		Preferences *string
+		UpdatedAt   time.Time
 	}
 
 	IUserRepository interface {
 		GetUserByID(context context.Context, id uint) (*Users, error)
+		GetUsersByIDs(c context.Context, ids []uint) ([]Users, error)
 		GetUserByCriteria(c context.Context, request RequestFilterUsers) (users []Users, count int64, cursor paginator.Cursor, err error)
+		UpsertUsersByExternalID(c context.Context, users []UserRecord) ([]uint, error)
+		FilterUnseenUsers(c context.Context, viewerID uint, users []Users) ([]Users, error)
+		MarkUsersSeen(c context.Context, viewerID uint, ids []uint) error
+		LikeUser(c context.Context, likerID, targetID uint) error
+		IncrementUserPopularity(c context.Context, id uint) error
+		GetUsersPopularity(c context.Context, ids []uint) (map[uint]float64, error)
+	}
+
+	RequestImportUser struct {
+		ExternalID  string       `json:"external_id" validate:"required,max=255"`
+		Name        string       `json:"name" validate:"required,max=155"`
+		Age         uint         `json:"age" validate:"gte=18,lte=120"`
+		Gender      string       `json:"gender" validate:"omitempty,oneof=male female"`
+		Latitude    *float64     `json:"latitude" validate:"required_with=Longitude,omitempty,latitude"`
+		Longitude   *float64     `json:"longitude" validate:"required_with=Latitude,omitempty,longitude"`
+		Interests   []string     `json:"interests" validate:"dive,required,max=64"`
+		Preferences *Preferences `json:"preferences" validate:"omitempty"`
 	}
 
 	RequestFilterUsers struct {
@@ -33,6 +68,48 @@ type (
 	}
 )
 
+// CacheVersion orders the cached profiles, so a profile read before an update never replaces the updated one
+func (user Users) CacheVersion() uint64 {
+	return uint64(user.UpdatedAt.UnixMicro())
+}
+
+// TableName :nodoc:
+func (UserRecord) TableName() string {
+	return "users"
+}
+
+func (request RequestImportUser) Validate() error {
+	if err := validate.Struct(request); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (request RequestImportUser) ToUserRecord() UserRecord {
+	record := UserRecord{
+		ExternalID: utils.TypeToPointerType(request.ExternalID),
+		Name:       request.Name,
+		Age:        request.Age,
+		Interests:  utils.ToPostgresTextArray(request.Interests),
+	}
+
+	if request.Gender != "" {
+		record.Gender = utils.TypeToPointerType(request.Gender)
+	}
+
+	if request.Latitude != nil && request.Longitude != nil {
+		// postgres point is stored as (longitude, latitude)
+		record.Location = utils.TypeToPointerType(utils.WriteStringTemplate("(%f,%f)", *request.Longitude, *request.Latitude))
+	}
+
+	if request.Preferences != nil {
+		record.Preferences = utils.TypeToPointerType(utils.Dump(request.Preferences))
+	}
+
+	return record
+}
+
 func (s *RequestFilterUsers) ToCursorInfo(cursor paginator.Cursor, count int64) CursorInfo {
 	cursorInfo := CursorInfo{
 		Size:      s.Size,
@@ -54,6 +131,38 @@ func (s *RequestFilterUsers) ToCursorInfo(cursor paginator.Cursor, count int64)
 	return cursorInfo
 }
 
+// CacheFilterKey normalizes the filters of the request, requests selecting the same users share the key.
+func (s *RequestFilterUsers) CacheFilterKey() string {
+	filter := url.Values{}
+	if s.Name != "" {
+		filter.Set("name", s.Name)
+	}
+
+	if s.Gender != "" {
+		filter.Set("gender", s.Gender)
+	}
+
+	if from, to, ok := s.AgeRange(); ok {
+		filter.Set("age", utils.WriteStringTemplate("%d-%d", from, to))
+	}
+
+	return filter.Encode()
+}
+
+// AgeRange returns the bounds of the age filter in ascending order, ok is false when the request has no age range.
+func (s *RequestFilterUsers) AgeRange() (from, to int, ok bool) {
+	if len(s.Age) != 2 {
+		return 0, 0, false
+	}
+
+	return min(s.Age[0], s.Age[1]), max(s.Age[0], s.Age[1]), true
+}
+
+// CachePageKey identifies the page of the request among the pages of its filter.
+func (s *RequestFilterUsers) CachePageKey() string {
+	return utils.WriteStringTemplate("%s:%s:%d:%s:%s", s.SortBy, s.SortDir, s.Size, s.CursorDir, s.Cursor)
+}
+
 func (s *RequestFilterUsers) SetDefaultValue() {
 	if _, ok := CustomerSortByValues[s.SortBy]; !ok {
 		// set ID as a default order by
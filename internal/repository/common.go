@@ -42,8 +42,8 @@ func filterByGender(gender string) func(db *gorm.DB) *gorm.DB {
 	}
 }
 
-func filterByAgeRange(ageRange []int) func(db *gorm.DB) *gorm.DB {
+func filterByAgeRange(from, to int) func(db *gorm.DB) *gorm.DB {
 	return func(db *gorm.DB) *gorm.DB {
-		return db.Where("age BETWEEN ? AND ?", ageRange[0], ageRange[1])
+		return db.Where("age BETWEEN ? AND ?", from, to)
 	}
 }
@@ -9,20 +9,35 @@ import (
 	"github.com/pilagod/gorm-cursor-paginator/v2/paginator"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strconv"
 )
 
+// maxCriteriaAgeBuckets caps the age bucket tags of a user list page, wider lists are tagged as not filtered by age
+const maxCriteriaAgeBuckets = 10
+
+// popularityTrimSlack is the share of its size the popularity ranking may grow over before it is trimmed
+const popularityTrimSlack = 0.1
+
 type UserRepository struct {
-	db    *gorm.DB
-	cache cacher.CacheManager
+	db        *gorm.DB
+	cache     cacher.CacheManager
+	userCache *cacher.Typed[entity.Users]
+
+	// seenProfiles remembers the profiles recently shown to each user
+	seenProfiles *cacher.BloomFilter
 }
 
 func NewUserRepository(
 	db *gorm.DB,
 	cache cacher.CacheManager,
+	seenProfiles *cacher.BloomFilter,
 ) entity.IUserRepository {
 	return &UserRepository{
-		db:    db,
-		cache: cache,
+		db:           db,
+		cache:        cache,
+		userCache:    cacher.NewTyped[entity.Users](cache),
+		seenProfiles: seenProfiles,
 	}
 }
 
@@ -32,47 +47,159 @@ func (repo *UserRepository) GetUserByID(ctx context.Context, id uint) (*entity.U
 		"customerID": id,
 	})
 
-	cacheKey := cacher.GetUserCacheKeyByID(id)
-	if config.EnableCaching() {
-		cachedItem, mutex, err := cacher.FindFromCacheByKey[*entity.Users](repo.cache, cacheKey)
-		if err != nil {
-			logger.Error(err)
+	cacheKey := cacher.GetUserCacheKeyByID(repo.cache, id)
+	user, err := repo.userCache.GetOrLoad(ctx, cacheKey, func(ctx context.Context) (*entity.Users, error) {
+		user := &entity.Users{}
+		err := repo.db.WithContext(ctx).Take(user, "id = ?", id).Error
+		switch err {
+		case nil:
+			return user, nil
+		case gorm.ErrRecordNotFound:
+			return nil, nil
+		default:
 			return nil, err
 		}
+	}, cacher.WithTags(cacher.GetUserCacheTagByID(id)))
+	if err != nil {
+		logger.WithField("cacheKey", cacheKey).Error(err)
+		return nil, err
+	}
 
-		defer cacher.SafeUnlock(mutex)
+	return user, nil
+}
 
-		if mutex == nil {
-			logger.WithField("cacheKey", cacheKey).Info("returning customer from redis cache")
-			return cachedItem, nil
-		}
+// GetUsersByIDs returns the users in the order of the given ids, reading the cached profiles in one round trip
+// and loading only the missing ones from the database. Unknown ids are skipped.
+func (repo *UserRepository) GetUsersByIDs(ctx context.Context, ids []uint) ([]entity.Users, error) {
+	logger := logrus.WithContext(ctx).WithFields(logrus.Fields{
+		"context": utils.DumpIncomingContext(ctx),
+		"ids":     ids,
+	})
+
+	if len(ids) == 0 {
+		return nil, nil
 	}
 
-	user := &entity.Users{}
-	err := repo.db.WithContext(ctx).Take(user, "id = ?", id).Error
-	switch err {
-	case nil:
-		if err := repo.cache.StoreWithoutBlocking(cacher.NewItem(cacheKey, utils.Dump(user))); err != nil {
-			logger.Error(err)
+	cacheKeys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		cacheKeys = append(cacheKeys, cacher.GetUserCacheKeyByID(repo.cache, id))
+	}
+
+	cachedValues, err := repo.cache.GetOrSetMultiCtx(ctx, cacheKeys, func(ctx context.Context, missingKeys []string) (map[string]any, error) {
+		missingIDs := make([]uint, 0, len(missingKeys))
+		for i, cacheKey := range cacheKeys {
+			if utils.Contains(missingKeys, cacheKey) {
+				missingIDs = append(missingIDs, ids[i])
+			}
 		}
 
-		return user, nil
-	case gorm.ErrRecordNotFound:
-		if err := repo.cache.StoreNil(cacheKey); err != nil {
-			logger.Error(err)
+		var users []entity.Users
+		if err := repo.db.WithContext(ctx).Find(&users, "id IN ?", missingIDs).Error; err != nil {
+			return nil, err
 		}
-		return nil, nil
-	default:
+
+		usersByKey := make(map[string]any, len(users))
+		for i := range users {
+			usersByKey[cacher.GetUserCacheKeyByID(repo.cache, users[i].ID)] = &users[i]
+		}
+
+		return usersByKey, nil
+	})
+	if err != nil {
+		logger.Error(err)
 		return nil, err
 	}
+
+	users := make([]entity.Users, 0, len(ids))
+	for _, cacheKey := range cacheKeys {
+		cachedValue, ok := cachedValues[cacheKey]
+		if !ok {
+			continue
+		}
+
+		var user entity.Users
+		if err := cacher.Unmarshal(cachedValue, &user); err != nil {
+			logger.WithField("cacheKey", cacheKey).Error(err)
+			return nil, err
+		}
+
+		users = append(users, user)
+	}
+
+	return users, nil
 }
 
+// GetUserByCriteria caches each page as the list of its user ids in the bucket of the filter, then hydrates the
+// users by id, so the cached pages stay small and never hold stale profiles.
 func (repo *UserRepository) GetUserByCriteria(ctx context.Context, request entity.RequestFilterUsers) (users []entity.Users, count int64, cursor paginator.Cursor, err error) {
 	logger := logrus.WithContext(ctx).WithFields(logrus.Fields{
 		"ctx":            utils.DumpIncomingContext(ctx),
 		"searchCriteria": utils.Dump(request),
 	})
 
+	bucket := cacher.GetUserCriteriaCacheBucket(repo.cache, request.CacheFilterKey())
+	pageKey := request.CachePageKey()
+
+	multiResponse, mu, err := cacher.FindMultiResponseFromCacheByKeyCtx(ctx, repo.cache, bucket, pageKey)
+	if err != nil {
+		// the page is still served from the database
+		logger.WithField("cacheBucket", bucket).Error(err)
+	}
+	defer cacher.SafeUnlock(mu)
+
+	if multiResponse != nil {
+		return repo.hydrateUsersPage(ctx, multiResponse)
+	}
+
+	users, count, cursor, err = repo.findUserByCriteria(ctx, request)
+	if err != nil {
+		logger.Error(err)
+		return
+	}
+
+	ids := make([]int64, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, int64(user.ID))
+	}
+
+	multiResponse = cacher.ToMultiResponse(ids, count)
+	multiResponse.Before, multiResponse.After = cursor.Before, cursor.After
+
+	item := cacher.NewItem(pageKey, multiResponse.ToByte())
+	item.AddTags(criteriaCacheTags(request)...)
+	if err := repo.cache.StoreHashMemberCtx(ctx, bucket, item); err != nil {
+		logger.WithField("cacheBucket", bucket).Error(err)
+	}
+
+	return users, count, cursor, nil
+}
+
+// hydrateUsersPage loads the users of a cached page, users deleted since the page was cached are skipped.
+func (repo *UserRepository) hydrateUsersPage(ctx context.Context, multiResponse *cacher.MultiResponse) (users []entity.Users, count int64, cursor paginator.Cursor, err error) {
+	ids := make([]uint, 0, len(multiResponse.IDs))
+	for _, id := range multiResponse.IDs {
+		ids = append(ids, uint(id))
+	}
+
+	users, err = repo.GetUsersByIDs(ctx, ids)
+	if err != nil {
+		return nil, 0, cursor, err
+	}
+
+	cursor = paginator.Cursor{
+		Before: multiResponse.Before,
+		After:  multiResponse.After,
+	}
+
+	return users, multiResponse.Count, cursor, nil
+}
+
+func (repo *UserRepository) findUserByCriteria(ctx context.Context, request entity.RequestFilterUsers) (users []entity.Users, count int64, cursor paginator.Cursor, err error) {
+	logger := logrus.WithContext(ctx).WithFields(logrus.Fields{
+		"ctx":            utils.DumpIncomingContext(ctx),
+		"searchCriteria": utils.Dump(request),
+	})
+
 	scopes := repo.buildFilterScopeByCriteria(request)
 
 	count, err = repo.countAll(ctx, scopes, request)
@@ -101,6 +228,266 @@ func (repo *UserRepository) GetUserByCriteria(ctx context.Context, request entit
 	return users, count, cursor, nil
 }
 
+// UpsertUsersByExternalID inserts users or updates the existing ones sharing the same external identifier,
+// then invalidates the cached profiles of the affected users and the cached user lists they were or are in.
+func (repo *UserRepository) UpsertUsersByExternalID(ctx context.Context, users []entity.UserRecord) ([]uint, error) {
+	logger := logrus.WithContext(ctx).WithFields(logrus.Fields{
+		"context": utils.DumpIncomingContext(ctx),
+		"total":   len(users),
+	})
+
+	if len(users) == 0 {
+		return nil, nil
+	}
+
+	// the updated users leave the lists of their previous gender and age
+	tags, err := repo.findPreviousCriteriaCacheTags(ctx, users)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	err = repo.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "external_id"}},
+			UpdateAll: true,
+		}).
+		Create(&users).
+		Error
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	ids := make([]uint, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, user.ID)
+		tags = append(tags, userCriteriaCacheTags(user)...)
+	}
+
+	if err := repo.cache.InvalidateTagsCtx(ctx, utils.Unique(tags)...); err != nil {
+		logger.Error(err)
+	}
+
+	repo.refreshUsersCache(ctx, ids)
+
+	return ids, nil
+}
+
+// findPreviousCriteriaCacheTags returns the criteria tags of the stored users sharing an external identifier with
+// the users.
+func (repo *UserRepository) findPreviousCriteriaCacheTags(ctx context.Context, users []entity.UserRecord) ([]string, error) {
+	externalIDs := make([]string, 0, len(users))
+	for _, user := range users {
+		if user.ExternalID != nil {
+			externalIDs = append(externalIDs, *user.ExternalID)
+		}
+	}
+
+	if len(externalIDs) == 0 {
+		return nil, nil
+	}
+
+	var previous []entity.UserRecord
+	err := repo.db.WithContext(ctx).
+		Select("age", "gender").
+		Where("external_id IN ?", externalIDs).
+		Find(&previous).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []string
+	for _, user := range previous {
+		tags = append(tags, userCriteriaCacheTags(user)...)
+	}
+
+	return tags, nil
+}
+
+// refreshUsersCache deletes the cached profiles of the written users, the deletion is replayed when it happens while
+// the cache breaker is open. The written profiles are then stored with their version, so a concurrent read of the
+// previous row can no longer replace them.
+func (repo *UserRepository) refreshUsersCache(ctx context.Context, ids []uint) {
+	logger := logrus.WithContext(ctx).WithFields(logrus.Fields{
+		"context": utils.DumpIncomingContext(ctx),
+		"ids":     ids,
+	})
+
+	cacheKeys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		cacheKeys = append(cacheKeys, cacher.GetUserCacheKeyByID(repo.cache, id))
+	}
+
+	if err := repo.cache.DeleteByKeysCtx(ctx, cacheKeys); err != nil {
+		logger.Error(err)
+	}
+
+	if err := repo.storeVersionedUsers(ctx, ids); err != nil {
+		logger.Error(err)
+	}
+}
+
+func (repo *UserRepository) storeVersionedUsers(ctx context.Context, ids []uint) error {
+	var users []entity.Users
+	if err := repo.db.WithContext(ctx).Find(&users, "id IN ?", ids).Error; err != nil {
+		return err
+	}
+
+	items := make([]cacher.Item, 0, len(users))
+	for i := range users {
+		cachedValue, err := repo.cache.Marshal(&users[i])
+		if err != nil {
+			return err
+		}
+
+		item := cacher.NewItem(cacher.GetUserCacheKeyByID(repo.cache, users[i].ID), cachedValue)
+		for _, o := range []func(cacher.Item){
+			cacher.WithVersion(users[i].CacheVersion()),
+			cacher.WithTags(cacher.GetUserCacheTagByID(users[i].ID)),
+		} {
+			o(item)
+		}
+
+		items = append(items, item)
+	}
+
+	return repo.cache.StoreMultiWithoutBlockingCtx(ctx, items)
+}
+
+// FilterUnseenUsers drops the users recently shown to the viewer. A few users never shown may be dropped as well,
+// at the error rate of the seen profiles filter.
+func (repo *UserRepository) FilterUnseenUsers(ctx context.Context, viewerID uint, users []entity.Users) ([]entity.Users, error) {
+	ids := make([]string, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, utils.IntToString(user.ID))
+	}
+
+	seen, err := repo.seenProfiles.MightContain(ctx, utils.IntToString(viewerID), ids...)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{
+			"context":  utils.DumpIncomingContext(ctx),
+			"viewerID": viewerID,
+		}).Error(err)
+		return nil, err
+	}
+
+	unseenUsers := make([]entity.Users, 0, len(users))
+	for i, user := range users {
+		if !seen[i] {
+			unseenUsers = append(unseenUsers, user)
+		}
+	}
+
+	return unseenUsers, nil
+}
+
+// MarkUsersSeen remembers the users as shown to the viewer for a day or two.
+func (repo *UserRepository) MarkUsersSeen(ctx context.Context, viewerID uint, ids []uint) error {
+	items := make([]string, 0, len(ids))
+	for _, id := range ids {
+		items = append(items, utils.IntToString(id))
+	}
+
+	if err := repo.seenProfiles.Add(ctx, utils.IntToString(viewerID), items...); err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{
+			"context":  utils.DumpIncomingContext(ctx),
+			"viewerID": viewerID,
+		}).Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// LikeUser records the like of the target by the liker and counts it in the popularity of the target, a user liked
+// again by the same liker is not counted twice. The like is committed before it is counted, a like which could
+// not be counted is missing from the ranking rather than counted again when it is retried.
+func (repo *UserRepository) LikeUser(ctx context.Context, likerID, targetID uint) error {
+	logger := logrus.WithContext(ctx).WithFields(logrus.Fields{
+		"context":  utils.DumpIncomingContext(ctx),
+		"likerID":  likerID,
+		"targetID": targetID,
+	})
+
+	result := repo.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&entity.UserLike{LikerID: likerID, TargetID: targetID})
+	if result.Error != nil {
+		logger.Error(result.Error)
+		return result.Error
+	}
+
+	// the liker already liked the target
+	if result.RowsAffected == 0 {
+		return nil
+	}
+
+	return repo.IncrementUserPopularity(ctx, targetID)
+}
+
+// IncrementUserPopularity counts a like received by the user, only the most liked users are kept in the ranking.
+// The ranking is trimmed once it grew popularityTrimSlack over its size, so it is not trimmed on every like.
+func (repo *UserRepository) IncrementUserPopularity(ctx context.Context, id uint) error {
+	logger := logrus.WithContext(ctx).WithFields(logrus.Fields{
+		"context": utils.DumpIncomingContext(ctx),
+		"userID":  id,
+	})
+
+	leaderboardKey := cacher.GetUserPopularityLeaderboardKey(repo.cache)
+	logger = logger.WithField("cacheKey", leaderboardKey)
+
+	if _, err := repo.cache.IncrementScore(ctx, leaderboardKey, utils.IntToString(id), 1); err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	// the like is counted, a ranking not trimmed now is trimmed by a next like
+	count, err := repo.cache.CountMembers(ctx, leaderboardKey)
+	if err != nil {
+		logger.Error(err)
+		return nil
+	}
+
+	maxSize := config.PopularityMaxSize()
+	if float64(count) <= float64(maxSize)*(1+popularityTrimSlack) {
+		return nil
+	}
+
+	if _, err := repo.cache.TrimMembers(ctx, leaderboardKey, maxSize); err != nil {
+		logger.Error(err)
+	}
+
+	return nil
+}
+
+// GetUsersPopularity returns the popularity score of the users, users out of the ranking are left out.
+func (repo *UserRepository) GetUsersPopularity(ctx context.Context, ids []uint) (map[uint]float64, error) {
+	members := make([]string, 0, len(ids))
+	for _, id := range ids {
+		members = append(members, utils.IntToString(id))
+	}
+
+	leaderboardKey := cacher.GetUserPopularityLeaderboardKey(repo.cache)
+	scores, err := repo.cache.MemberScores(ctx, leaderboardKey, members)
+	if err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{
+			"context":  utils.DumpIncomingContext(ctx),
+			"cacheKey": leaderboardKey,
+		}).Error(err)
+		return nil, err
+	}
+
+	popularity := make(map[uint]float64, len(scores))
+	for i, id := range ids {
+		if score, ok := scores[members[i]]; ok {
+			popularity[id] = score
+		}
+	}
+
+	return popularity, nil
+}
+
 func (repo *UserRepository) buildFilterScopeByCriteria(request entity.RequestFilterUsers) []func(db *gorm.DB) *gorm.DB {
 	var scopes []func(db *gorm.DB) *gorm.DB
 
@@ -112,13 +499,56 @@ func (repo *UserRepository) buildFilterScopeByCriteria(request entity.RequestFil
 		scopes = append(scopes, filterByGender(request.Gender))
 	}
 
-	if request.Age != nil {
-		scopes = append(scopes, filterByAgeRange(request.Age))
+	if from, to, ok := request.AgeRange(); ok {
+		scopes = append(scopes, filterByAgeRange(from, to))
 	}
 
 	return scopes
 }
 
+// criteriaCacheTags tags a page with every gender and age bucket its filter selects. A filter without gender or
+// age, or spanning more than maxCriteriaAgeBuckets, is tagged with cacher.UserCriteriaAny for it.
+func criteriaCacheTags(request entity.RequestFilterUsers) []string {
+	gender := utils.ValueOrDefault[string](request.Gender, cacher.UserCriteriaAny)
+
+	from, to, ok := request.AgeRange()
+	if !ok {
+		return []string{cacher.GetUserCriteriaCacheTag(gender, cacher.UserCriteriaAny)}
+	}
+
+	first, last := max(from, 0)/cacher.UserCriteriaAgeBucket, to/cacher.UserCriteriaAgeBucket
+	if last-first >= maxCriteriaAgeBuckets {
+		return []string{cacher.GetUserCriteriaCacheTag(gender, cacher.UserCriteriaAny)}
+	}
+
+	var tags []string
+	for bucket := first; bucket <= last; bucket++ {
+		tags = append(tags, cacher.GetUserCriteriaCacheTag(gender, strconv.Itoa(bucket*cacher.UserCriteriaAgeBucket)))
+	}
+
+	return tags
+}
+
+// userCriteriaCacheTags lists the tags of the pages which may hold the user, whether they filter by its gender
+// and age bucket or not.
+func userCriteriaCacheTags(user entity.UserRecord) []string {
+	genders := []string{cacher.UserCriteriaAny}
+	if user.Gender != nil && *user.Gender != "" {
+		genders = append(genders, *user.Gender)
+	}
+
+	ageBucket := strconv.Itoa(int(user.Age) / cacher.UserCriteriaAgeBucket * cacher.UserCriteriaAgeBucket)
+
+	tags := make([]string, 0, 2*len(genders))
+	for _, gender := range genders {
+		tags = append(tags,
+			cacher.GetUserCriteriaCacheTag(gender, cacher.UserCriteriaAny),
+			cacher.GetUserCriteriaCacheTag(gender, ageBucket))
+	}
+
+	return tags
+}
+
 func (repo *UserRepository) countAll(ctx context.Context, scopes []func(*gorm.DB) *gorm.DB, criteria entity.RequestFilterUsers) (int64, error) {
 	var count int64
 
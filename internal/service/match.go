@@ -4,9 +4,14 @@ import (
 	"context"
 	"github.com/mazharul-islam/internal/entity"
 	"github.com/mazharul-islam/utils"
+	"github.com/pilagod/gorm-cursor-paginator/v2/paginator"
 	"github.com/sirupsen/logrus"
+	"sort"
 )
 
+// maxUnseenPageFetches caps the pages read to fill a page of recommendations with users not seen yet
+const maxUnseenPageFetches = 5
+
 type MatchService struct {
 	userRepository entity.IUserRepository
 }
@@ -41,13 +46,105 @@ func (service *MatchService) GetListRecommendations(ctx context.Context, id uint
 	requestFilter.Gender = preferences.PreferredGender
 	requestFilter.Age = preferences.PreferredAgeRange
 
-	recommendationUsers, totalItems, cursor, err := service.userRepository.GetUserByCriteria(ctx, requestFilter)
-
+	//Seen Profiles: skip the users recently shown, recommendations are still served when the filter is unavailable
+	recommendationUsers, totalItems, cursor, err := service.findUnseenUsers(ctx, id, requestFilter)
 	if err != nil {
 		logger.Error(err)
 	}
 
+	shownIDs := make([]uint, 0, len(recommendationUsers))
+	for _, user := range recommendationUsers {
+		shownIDs = append(shownIDs, user.ID)
+	}
+
+	if err := service.userRepository.MarkUsersSeen(ctx, id, shownIDs); err != nil {
+		logger.Error(err)
+	}
+
+	//Popularity: Rank the most liked users higher, the page keeps its order when the ranking is unavailable
+	if popularity, err := service.userRepository.GetUsersPopularity(ctx, shownIDs); err == nil {
+		sort.SliceStable(recommendationUsers, func(i, j int) bool {
+			return popularity[recommendationUsers[i].ID] > popularity[recommendationUsers[j].ID]
+		})
+	}
+
 	//Mutual Interests: Rank users higher if they share more common interests.
 
 	return recommendationUsers, requestFilter.ToCursorInfo(cursor, totalItems), nil
 }
+
+// findUnseenUsers reads the pages of the filter from the cursor until the page is filled with users the viewer has
+// not seen, each read asking for the users still missing so the returned cursor follows the last user read. The
+// count leaves out the seen users met on the way.
+func (service *MatchService) findUnseenUsers(ctx context.Context, viewerID uint, request entity.RequestFilterUsers) (users []entity.Users, count int64, cursor paginator.Cursor, err error) {
+	for fetch := 0; fetch < maxUnseenPageFetches && int64(len(users)) < request.Size; fetch++ {
+		pageRequest := request
+		pageRequest.Size = request.Size - int64(len(users))
+
+		pageUsers, pageCount, pageCursor, err := service.userRepository.GetUserByCriteria(ctx, pageRequest)
+		if err != nil {
+			return users, count, cursor, err
+		}
+
+		if fetch == 0 {
+			count, cursor = pageCount, pageCursor
+		}
+
+		unseenUsers, err := service.userRepository.FilterUnseenUsers(ctx, viewerID, pageUsers)
+		if err != nil {
+			unseenUsers = pageUsers
+		}
+		count -= int64(len(pageUsers) - len(unseenUsers))
+
+		// the pages before the cursor are read backwards
+		var next *string
+		if request.CursorDir == entity.CursorDirectionPrev {
+			users, cursor.Before, next = append(unseenUsers, users...), pageCursor.Before, pageCursor.Before
+		} else {
+			users, cursor.After, next = append(users, unseenUsers...), pageCursor.After, pageCursor.After
+		}
+
+		if next == nil {
+			break
+		}
+		request.Cursor = *next
+	}
+
+	return users, count, cursor, nil
+}
+
+func (service *MatchService) LikeUser(ctx context.Context, id uint, request entity.RequestLikeUser) error {
+	logger := logrus.WithContext(ctx).WithFields(logrus.Fields{
+		"ctx":          utils.DumpIncomingContext(ctx),
+		"userID":       id,
+		"targetUserID": request.TargetUserID,
+	})
+
+	if id == request.TargetUserID {
+		return ErrBadRequest
+	}
+
+	user, err := service.userRepository.GetUserByID(ctx, id)
+	if err != nil {
+		logger.Error(err)
+		return ErrInternalServerError
+	}
+
+	targetUser, err := service.userRepository.GetUserByID(ctx, request.TargetUserID)
+	if err != nil {
+		logger.Error(err)
+		return ErrInternalServerError
+	}
+
+	if user == nil || targetUser == nil {
+		return ErrNotFound
+	}
+
+	//Popularity: every user liking the target moves it up in the recommendations
+	if err := service.userRepository.LikeUser(ctx, id, targetUser.ID); err != nil {
+		logger.Error(err)
+		return ErrInternalServerError
+	}
+
+	return nil
+}
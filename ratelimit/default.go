@@ -0,0 +1,6 @@
+package ratelimit
+
+const (
+	defaultPrefixKey = "mazharul-islam"
+	headerDeviceID   = "Device-Id"
+)
@@ -0,0 +1,8 @@
+package ratelimit
+
+import "errors"
+
+var (
+	ErrRateLimitExceeded     = errors.New("rate limit exceeded")
+	ErrUnexpectedScriptReply = errors.New("unexpected rate limit script reply")
+)
@@ -0,0 +1,85 @@
+package ratelimit
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mazharul-islam/utils/httpresponse"
+	"github.com/sirupsen/logrus"
+)
+
+// KeyFn returns the identity a request is limited by, requests with an empty key are not limited.
+type KeyFn func(c *gin.Context) string
+
+// ClientKey limits the authenticated user, or else the client ip. Identities sent by the client, such as a header
+// or the user id of the path, are not used since a client could change them to get a new quota on each request.
+func ClientKey(c *gin.Context) string {
+	if user := c.GetString(gin.AuthUserKey); user != "" {
+		return "user:" + user
+	}
+
+	return "ip:" + c.ClientIP()
+}
+
+// DeviceKey limits the authenticated user, or else the device of the Device-Id header, or else the client ip. It
+// throttles each device separately, e.g. the swipes of users sharing an ip behind a NAT. Unlike ClientKey it trusts
+// the header, a client changing its Device-Id gets a new quota.
+func DeviceKey(c *gin.Context) string {
+	if user := c.GetString(gin.AuthUserKey); user != "" {
+		return "user:" + user
+	}
+
+	if device := c.GetHeader(headerDeviceID); device != "" {
+		return "device:" + device
+	}
+
+	return "ip:" + c.ClientIP()
+}
+
+// Middleware limits the requests of each client, see ClientKey.
+func Middleware(limiter Limiter) gin.HandlerFunc {
+	return MiddlewareWithKey(limiter, ClientKey)
+}
+
+// MiddlewareWithKey limits the requests by the key returned by keyFn. The quota is reported in the X-RateLimit-*
+// headers and rejected requests get 429 with Retry-After. Requests are let through when redis fails.
+func MiddlewareWithKey(limiter Limiter, keyFn KeyFn) gin.HandlerFunc {
+	rateLimitExceeded := httpresponse.NewHTTPError().WithCode(http.StatusTooManyRequests).WithMessage(ErrRateLimitExceeded)
+
+	return func(c *gin.Context) {
+		key := keyFn(c)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		result, err := limiter.Allow(c, key)
+		if err != nil {
+			logrus.WithContext(c).WithField("rateLimitKey", key).Error(err)
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter.Seconds())))
+
+		if !result.Allowed {
+			if result.RetryAfter > 0 {
+				c.Header("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter.Seconds())))
+			}
+
+			httpresponse.Error(c, rateLimitExceeded)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func ceilSeconds(seconds float64) int {
+	return int(math.Ceil(seconds))
+}
@@ -0,0 +1,90 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	redigo "github.com/gomodule/redigo/redis"
+	"github.com/mazharul-islam/config"
+	"github.com/mazharul-islam/utils"
+)
+
+type (
+	// Limiter decides whether a request identified by key may proceed. Limits are shared by every instance
+	// using the same redis, since the decision is taken atomically by a script.
+	Limiter interface {
+		// Allow takes a single unit of quota for the key.
+		Allow(ctx context.Context, key string) (Result, error)
+
+		// AllowN takes n units of quota for the key, nothing is taken when the request is not allowed.
+		AllowN(ctx context.Context, key string, n int) (Result, error)
+	}
+
+	// Result is the outcome of a rate limit decision.
+	Result struct {
+		Allowed bool
+		// Limit is the maximum quota of a key
+		Limit int
+		// Remaining is the quota left after this request
+		Remaining int
+		// ResetAfter is the time until the quota is fully restored
+		ResetAfter time.Duration
+		// RetryAfter is the time until the request would be allowed, zero when allowed
+		// and -1 when it can never be allowed because it exceeds the limit
+		RetryAfter time.Duration
+	}
+
+	// limiter holds what the algorithms have in common
+	limiter struct {
+		connPool *redigo.Pool
+		name     string
+		prefix   string
+		script   *redigo.Script
+	}
+)
+
+func newLimiter(connPool *redigo.Pool, name string, script *redigo.Script) limiter {
+	return limiter{
+		connPool: connPool,
+		name:     name,
+		prefix:   utils.WriteStringTemplate("%s_%s", defaultPrefixKey, config.EnvironmentMode()),
+		script:   script,
+	}
+}
+
+// key builds the redis key of a limited identity, e.g. mazharul-islam_dev_ratelimit:api:{user:1}
+func (limiter limiter) key(key string) string {
+	return utils.WriteStringTemplate("%s_ratelimit:%s:{%s}", limiter.prefix, limiter.name, key)
+}
+
+// run evaluates the script of the limiter, which returns allowed, remaining, reset after ms and retry after ms.
+func (limiter limiter) run(ctx context.Context, key string, limit int, args ...any) (Result, error) {
+	client, err := limiter.connPool.GetContext(ctx)
+	if err != nil {
+		return Result{}, err
+	}
+	defer utils.WrapCloser(client.Close)
+
+	values, err := redigo.Int64s(limiter.script.DoContext(ctx, client, append([]any{limiter.key(key)}, args...)...))
+	if err != nil {
+		return Result{}, err
+	}
+
+	if len(values) != 4 {
+		return Result{}, ErrUnexpectedScriptReply
+	}
+
+	result := Result{
+		Allowed:    values[0] == 1,
+		Limit:      limit,
+		Remaining:  int(values[1]),
+		ResetAfter: time.Duration(values[2]) * time.Millisecond,
+		RetryAfter: time.Duration(values[3]) * time.Millisecond,
+	}
+
+	if values[3] < 0 {
+		result.RetryAfter = -1
+	}
+
+	return result, nil
+}
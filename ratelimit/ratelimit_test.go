@@ -0,0 +1,164 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	redigo "github.com/gomodule/redigo/redis"
+)
+
+func newTestPool(t *testing.T) (*redigo.Pool, *miniredis.Miniredis) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	pool := &redigo.Pool{
+		Dial: func() (redigo.Conn, error) {
+			return redigo.Dial("tcp", server.Addr())
+		},
+	}
+	t.Cleanup(func() { _ = pool.Close() })
+
+	return pool, server
+}
+
+func TestSlidingWindow(t *testing.T) {
+	pool, server := newTestPool(t)
+	ctx := context.Background()
+	now := time.Now()
+	server.SetTime(now)
+
+	limiter := NewSlidingWindow(pool, "test", 3, time.Minute)
+	for i := 0; i < 3; i++ {
+		result, err := limiter.Allow(ctx, "user:1")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !result.Allowed || result.Remaining != 2-i {
+			t.Fatalf("request %d = %+v, want allowed with %d remaining", i, result, 2-i)
+		}
+	}
+
+	result, err := limiter.Allow(ctx, "user:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Allowed || result.RetryAfter != time.Minute {
+		t.Errorf("request over the limit = %+v, want rejected for a minute", result)
+	}
+
+	// another key has its own quota
+	if result, err := limiter.Allow(ctx, "user:2"); err != nil || !result.Allowed {
+		t.Errorf("request of another key = %+v, %v, want allowed", result, err)
+	}
+
+	// the first requests leave the window
+	server.SetTime(now.Add(time.Minute + time.Millisecond))
+	if result, err := limiter.Allow(ctx, "user:1"); err != nil || !result.Allowed {
+		t.Errorf("request after the window = %+v, %v, want allowed", result, err)
+	}
+
+	if result, err := limiter.AllowN(ctx, "user:3", 4); err != nil || result.Allowed || result.RetryAfter != -1 {
+		t.Errorf("AllowN over the limit = %+v, %v, want never allowed", result, err)
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	pool, server := newTestPool(t)
+	ctx := context.Background()
+	now := time.Now()
+	server.SetTime(now)
+
+	// one token every 100ms
+	limiter := NewTokenBucket(pool, "test", 2, 200*time.Millisecond)
+	for i := 0; i < 2; i++ {
+		if result, err := limiter.Allow(ctx, "device:a"); err != nil || !result.Allowed {
+			t.Fatalf("request %d = %+v, %v, want allowed", i, result, err)
+		}
+	}
+
+	result, err := limiter.Allow(ctx, "device:a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Allowed || result.RetryAfter != 100*time.Millisecond || result.ResetAfter != 200*time.Millisecond {
+		t.Errorf("request of an empty bucket = %+v, want rejected for 100ms", result)
+	}
+
+	server.SetTime(now.Add(100 * time.Millisecond))
+	if result, err := limiter.Allow(ctx, "device:a"); err != nil || !result.Allowed || result.Remaining != 0 {
+		t.Errorf("request after a refill = %+v, %v, want allowed with 0 remaining", result, err)
+	}
+
+	if result, err := limiter.AllowN(ctx, "device:b", 3); err != nil || result.Allowed || result.RetryAfter != -1 {
+		t.Errorf("AllowN over the burst = %+v, %v, want never allowed", result, err)
+	}
+}
+
+func TestDeviceKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		device string
+		user   string
+		want   string
+	}{
+		{name: "device header", device: "5d47eb91", want: "device:5d47eb91"},
+		{name: "client ip without header", want: "ip:192.0.2.1"},
+		{name: "authenticated user", device: "5d47eb91", user: "alice", want: "user:alice"},
+	}
+
+	for _, test := range tests {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/v1/match/like/user/1", nil)
+		c.Request.RemoteAddr = "192.0.2.1:1234"
+		if test.device != "" {
+			c.Request.Header.Set(headerDeviceID, test.device)
+		}
+		if test.user != "" {
+			c.Set(gin.AuthUserKey, test.user)
+		}
+
+		if key := DeviceKey(c); key != test.want {
+			t.Errorf("%s: DeviceKey = %s, want %s", test.name, key, test.want)
+		}
+	}
+}
+
+func TestMiddlewareRejectsPerDevice(t *testing.T) {
+	pool, _ := newTestPool(t)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(MiddlewareWithKey(NewSlidingWindow(pool, "test", 1, time.Minute), DeviceKey))
+	router.POST("/like", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	like := func(device string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPost, "/like", nil)
+		request.Header.Set(headerDeviceID, device)
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	if code := like("a").Code; code != http.StatusNoContent {
+		t.Fatalf("first like = %d, want %d", code, http.StatusNoContent)
+	}
+
+	rejected := like("a")
+	if rejected.Code != http.StatusTooManyRequests || rejected.Header().Get("Retry-After") != "60" {
+		t.Errorf("second like = %d with Retry-After %q, want %d after 60s",
+			rejected.Code, rejected.Header().Get("Retry-After"), http.StatusTooManyRequests)
+	}
+
+	// another device behind the same ip has its own quota
+	if code := like("b").Code; code != http.StatusNoContent {
+		t.Errorf("like of another device = %d, want %d", code, http.StatusNoContent)
+	}
+}
@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	redigo "github.com/gomodule/redigo/redis"
+	"github.com/google/uuid"
+)
+
+// The sliding window keeps a log of the granted requests in a sorted set scored by their time in ms, so the limit
+// holds for any window and not only for fixed buckets. The log expires with its window.
+//
+// KEYS[1]: log key, ARGV[1]: window, ARGV[2]: limit, ARGV[3]: n, ARGV[4]: unique request id
+var slidingWindowScript = redigo.NewScript(1, `
+-- the clock of redis is shared by every replica, unlike the clocks of the api servers
+redis.replicate_commands()
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local window = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+local n = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+
+local allowed = 0
+local retryAfter = 0
+if count + n <= limit then
+	for i = 1, n do
+		redis.call('ZADD', KEYS[1], now, ARGV[4] .. ':' .. i)
+	end
+	count = count + n
+	allowed = 1
+elseif n > limit then
+	retryAfter = -1
+else
+	-- wait until enough of the oldest requests leave the window
+	local oldest = redis.call('ZRANGE', KEYS[1], count + n - limit - 1, count + n - limit - 1, 'WITHSCORES')
+	retryAfter = tonumber(oldest[2]) + window - now
+end
+
+local resetAfter = 0
+if count > 0 then
+	local newest = redis.call('ZRANGE', KEYS[1], -1, -1, 'WITHSCORES')
+	resetAfter = tonumber(newest[2]) + window - now
+	redis.call('PEXPIRE', KEYS[1], resetAfter)
+end
+
+return {allowed, math.max(0, limit - count), resetAfter, retryAfter}
+`)
+
+type slidingWindow struct {
+	limiter
+	limit  int
+	window time.Duration
+}
+
+// NewSlidingWindow creates a Limiter allowing limit requests per key in any window of the given duration.
+// The name scopes the quota, limiters sharing a name share their quota.
+func NewSlidingWindow(connPool *redigo.Pool, name string, limit int, window time.Duration) Limiter {
+	return &slidingWindow{
+		limiter: newLimiter(connPool, name, slidingWindowScript),
+		limit:   limit,
+		window:  window,
+	}
+}
+
+// Allow takes a single request of the window for the key.
+func (window *slidingWindow) Allow(ctx context.Context, key string) (Result, error) {
+	return window.AllowN(ctx, key, 1)
+}
+
+// AllowN takes n requests of the window for the key.
+func (window *slidingWindow) AllowN(ctx context.Context, key string, n int) (Result, error) {
+	return window.run(ctx, key, window.limit, window.window.Milliseconds(), window.limit, n, uuid.NewString())
+}
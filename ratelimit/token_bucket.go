@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	redigo "github.com/gomodule/redigo/redis"
+)
+
+// The token bucket is a hash holding the tokens left and the time in ms they were counted. Tokens are refilled
+// lazily on each request, so the bucket needs no background job and expires once it would be full again.
+//
+// KEYS[1]: bucket key, ARGV[1]: refill rate per ms, ARGV[2]: burst, ARGV[3]: n
+var tokenBucketScript = redigo.NewScript(1, `
+-- the clock of redis is shared by every replica, unlike the clocks of the api servers
+redis.replicate_commands()
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local n = tonumber(ARGV[3])
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'updated_at')
+local tokens = tonumber(bucket[1]) or burst
+local updatedAt = tonumber(bucket[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - updatedAt) * rate)
+
+local allowed = 0
+local retryAfter = 0
+if tokens >= n then
+	tokens = tokens - n
+	allowed = 1
+elseif n > burst then
+	retryAfter = -1
+else
+	retryAfter = math.ceil((n - tokens) / rate)
+end
+
+local resetAfter = math.ceil((burst - tokens) / rate)
+if resetAfter > 0 then
+	redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated_at', now)
+	redis.call('PEXPIRE', KEYS[1], resetAfter)
+else
+	redis.call('DEL', KEYS[1])
+end
+
+return {allowed, math.floor(tokens), resetAfter, retryAfter}
+`)
+
+type tokenBucket struct {
+	limiter
+	burst int
+	rate  float64
+}
+
+// NewTokenBucket creates a Limiter allowing bursts of up to burst requests per key, refilled at burst tokens
+// per period. The name scopes the quota, limiters sharing a name share their quota.
+func NewTokenBucket(connPool *redigo.Pool, name string, burst int, period time.Duration) Limiter {
+	return &tokenBucket{
+		limiter: newLimiter(connPool, name, tokenBucketScript),
+		burst:   burst,
+		rate:    float64(burst) / float64(period.Milliseconds()),
+	}
+}
+
+// Allow takes a single token of the bucket of the key.
+func (bucket *tokenBucket) Allow(ctx context.Context, key string) (Result, error) {
+	return bucket.AllowN(ctx, key, 1)
+}
+
+// AllowN takes n tokens of the bucket of the key.
+func (bucket *tokenBucket) AllowN(ctx context.Context, key string, n int) (Result, error) {
+	return bucket.run(ctx, key, bucket.burst, bucket.rate, bucket.burst, n)
+}
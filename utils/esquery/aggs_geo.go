@@ -0,0 +1,208 @@
+package esquery
+
+//----------------------------------------------------------------------------//
+
+// GeoDistanceAggregation represents an aggregation of type "geo_distance", as
+// described in https://www.elastic.co/guide/en/elasticsearch/reference/current/
+//
+//	search-aggregations-bucket-geodistance-aggregation.html
+type GeoDistanceAggregation struct {
+	name         string
+	field        string
+	origin       GeoPoint
+	unit         string
+	distanceType string
+	keyed        *bool
+	ranges       []map[string]interface{}
+	aggs         []Aggregation
+}
+
+// GeoDistanceAgg creates a new aggregation of type "geo_distance", bucketing
+// the documents by the distance of their field to the origin. The method name
+// includes the "Agg" suffix to prevent conflict with the "geo_distance" query.
+func GeoDistanceAgg(name, field string, origin GeoPoint) *GeoDistanceAggregation {
+	return &GeoDistanceAggregation{
+		name:   name,
+		field:  field,
+		origin: origin,
+		ranges: []map[string]interface{}{},
+	}
+}
+
+// Name returns the name of the aggregation.
+func (agg *GeoDistanceAggregation) Name() string {
+	return agg.name
+}
+
+// Unit sets the unit of the ranges, e.g. "km" (default "m").
+func (agg *GeoDistanceAggregation) Unit(unit string) *GeoDistanceAggregation {
+	agg.unit = unit
+	return agg
+}
+
+// DistanceType sets how the distance is computed, "arc" (default) or "plane".
+func (agg *GeoDistanceAggregation) DistanceType(distanceType string) *GeoDistanceAggregation {
+	agg.distanceType = distanceType
+	return agg
+}
+
+// Keyed sets whether the buckets are returned as a map keyed by range instead
+// of a list.
+func (agg *GeoDistanceAggregation) Keyed(b bool) *GeoDistanceAggregation {
+	agg.keyed = &b
+	return agg
+}
+
+// AddRange adds a bucket for the distances from (included) to (excluded), a
+// nil bound leaves the range open on that side.
+func (agg *GeoDistanceAggregation) AddRange(from, to *float64) *GeoDistanceAggregation {
+	r := make(map[string]interface{})
+	if from != nil {
+		r["from"] = *from
+	}
+	if to != nil {
+		r["to"] = *to
+	}
+
+	agg.ranges = append(agg.ranges, r)
+	return agg
+}
+
+// Aggs sets sub-aggregations for the aggregation.
+func (agg *GeoDistanceAggregation) Aggs(aggs ...Aggregation) *GeoDistanceAggregation {
+	agg.aggs = aggs
+	return agg
+}
+
+// Map returns a map representation of the aggregation, thus implementing the
+// Mappable interface.
+func (agg *GeoDistanceAggregation) Map() map[string]interface{} {
+	innerMap := map[string]interface{}{
+		"field":  agg.field,
+		"origin": agg.origin.Map(),
+		"ranges": agg.ranges,
+	}
+
+	if agg.unit != "" {
+		innerMap["unit"] = agg.unit
+	}
+	if agg.distanceType != "" {
+		innerMap["distance_type"] = agg.distanceType
+	}
+	if agg.keyed != nil {
+		innerMap["keyed"] = *agg.keyed
+	}
+
+	outerMap := map[string]interface{}{
+		"geo_distance": innerMap,
+	}
+	if len(agg.aggs) > 0 {
+		subAggs := make(map[string]map[string]interface{})
+		for _, sub := range agg.aggs {
+			subAggs[sub.Name()] = sub.Map()
+		}
+		outerMap["aggs"] = subAggs
+	}
+
+	return outerMap
+}
+
+//----------------------------------------------------------------------------//
+
+// GeoHashGridAggregation represents an aggregation of type "geohash_grid", as
+// described in https://www.elastic.co/guide/en/elasticsearch/reference/current/
+//
+//	search-aggregations-bucket-geohashgrid-aggregation.html
+type GeoHashGridAggregation struct {
+	name        string
+	field       string
+	precision   interface{}
+	size        *uint64
+	shardSize   *uint64
+	topLeft     *GeoPoint
+	bottomRight *GeoPoint
+	aggs        []Aggregation
+}
+
+// GeoHashGrid creates a new aggregation of type "geohash_grid", bucketing the
+// documents by the geohash cell of their field.
+func GeoHashGrid(name, field string) *GeoHashGridAggregation {
+	return &GeoHashGridAggregation{
+		name:  name,
+		field: field,
+	}
+}
+
+// Name returns the name of the aggregation.
+func (agg *GeoHashGridAggregation) Name() string {
+	return agg.name
+}
+
+// Precision sets the geohash length of the cells, either a level between 1
+// and 12 or a distance such as "5km".
+func (agg *GeoHashGridAggregation) Precision(precision interface{}) *GeoHashGridAggregation {
+	agg.precision = precision
+	return agg
+}
+
+// Size sets the maximum number of cells to return.
+func (agg *GeoHashGridAggregation) Size(size uint64) *GeoHashGridAggregation {
+	agg.size = &size
+	return agg
+}
+
+// ShardSize sets how many cells to request from each shard.
+func (agg *GeoHashGridAggregation) ShardSize(size uint64) *GeoHashGridAggregation {
+	agg.shardSize = &size
+	return agg
+}
+
+// Bounds restricts the cells to the box of the provided corners.
+func (agg *GeoHashGridAggregation) Bounds(topLeft, bottomRight GeoPoint) *GeoHashGridAggregation {
+	agg.topLeft = &topLeft
+	agg.bottomRight = &bottomRight
+	return agg
+}
+
+// Aggs sets sub-aggregations for the aggregation.
+func (agg *GeoHashGridAggregation) Aggs(aggs ...Aggregation) *GeoHashGridAggregation {
+	agg.aggs = aggs
+	return agg
+}
+
+// Map returns a map representation of the aggregation, thus implementing the
+// Mappable interface.
+func (agg *GeoHashGridAggregation) Map() map[string]interface{} {
+	innerMap := map[string]interface{}{
+		"field": agg.field,
+	}
+
+	if agg.precision != nil {
+		innerMap["precision"] = agg.precision
+	}
+	if agg.size != nil {
+		innerMap["size"] = *agg.size
+	}
+	if agg.shardSize != nil {
+		innerMap["shard_size"] = *agg.shardSize
+	}
+	if agg.topLeft != nil && agg.bottomRight != nil {
+		innerMap["bounds"] = map[string]interface{}{
+			"top_left":     agg.topLeft.Map(),
+			"bottom_right": agg.bottomRight.Map(),
+		}
+	}
+
+	outerMap := map[string]interface{}{
+		"geohash_grid": innerMap,
+	}
+	if len(agg.aggs) > 0 {
+		subAggs := make(map[string]map[string]interface{})
+		for _, sub := range agg.aggs {
+			subAggs[sub.Name()] = sub.Map()
+		}
+		outerMap["aggs"] = subAggs
+	}
+
+	return outerMap
+}
@@ -0,0 +1,64 @@
+package esquery
+
+import "testing"
+
+func TestGeoAggregations(t *testing.T) {
+	from, to := 100.0, 300.0
+
+	runMapTests(t, []mapTest{
+		{
+			"geo_distance without ranges",
+			GeoDistanceAgg("rings", "location", GeoPoint{Lat: 52.37, Lon: 4.89}),
+			`{"geo_distance": {"field": "location", "origin": {"lat": 52.37, "lon": 4.89}, "ranges": []}}`,
+		},
+		{
+			"geo_distance with ranges and sub-aggregations",
+			GeoDistanceAgg("rings", "location", GeoPoint{Lat: 52.37, Lon: 4.89}).
+				Unit("km").
+				DistanceType("plane").
+				Keyed(true).
+				AddRange(nil, &from).
+				AddRange(&from, &to).
+				AddRange(&to, nil).
+				Aggs(Avg("avg_age", "age")),
+			`{
+				"geo_distance": {
+					"field": "location",
+					"origin": {"lat": 52.37, "lon": 4.89},
+					"unit": "km",
+					"distance_type": "plane",
+					"keyed": true,
+					"ranges": [{"to": 100}, {"from": 100, "to": 300}, {"from": 300}]
+				},
+				"aggs": {"avg_age": {"avg": {"field": "age"}}}
+			}`,
+		},
+		{
+			"geohash_grid with default options",
+			GeoHashGrid("cells", "location"),
+			`{"geohash_grid": {"field": "location"}}`,
+		},
+		{
+			"geohash_grid with bounds and sub-aggregations",
+			GeoHashGrid("cells", "location").
+				Precision("5km").
+				Size(100).
+				ShardSize(300).
+				Bounds(GeoPoint{Lat: 52.6, Lon: 4.7}, GeoPoint{Lat: 52.2, Lon: 5.1}).
+				Aggs(Max("max_age", "age")),
+			`{
+				"geohash_grid": {
+					"field": "location",
+					"precision": "5km",
+					"size": 100,
+					"shard_size": 300,
+					"bounds": {
+						"top_left": {"lat": 52.6, "lon": 4.7},
+						"bottom_right": {"lat": 52.2, "lon": 5.1}
+					}
+				},
+				"aggs": {"max_age": {"max": {"field": "age"}}}
+			}`,
+		},
+	})
+}
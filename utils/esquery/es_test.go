@@ -0,0 +1,40 @@
+package esquery
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+type mapTest struct {
+	name string
+	q    Mappable
+	exp  string
+}
+
+// runMapTests compares the JSON of the map of each test with the expected JSON, regardless of the key order.
+func runMapTests(t *testing.T, tests []mapTest) {
+	t.Helper()
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := json.Marshal(test.q.Map())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got, exp interface{}
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := json.Unmarshal([]byte(test.exp), &exp); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got, exp) {
+				t.Errorf("got %s, want %s", data, test.exp)
+			}
+		})
+	}
+}
@@ -0,0 +1,529 @@
+package esquery
+
+// FunctionScoreQuery represents a compound query of type "function_score", as
+// described in
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/query-dsl-function-score-query.html
+//
+// For example, users closer to a point, more recently active and sharing more
+// interests can be ranked first with:
+//
+//	FunctionScore(Terms("interests", "music", "hiking")).
+//	    Functions(
+//	        Gauss("location", GeoPoint{Lat: -6.2, Lon: 106.8}, "5km"),
+//	        Exp("last_active_at", "now", "7d").Decay(0.5),
+//	        FieldValueFactor("shared_interests").Modifier(FieldValueModifierLog1p),
+//	    ).
+//	    ScoreMode(ScoreModeSum).
+//	    BoostMode(BoostModeMultiply)
+type FunctionScoreQuery struct {
+	query     Mappable
+	functions []Mappable
+	scoreMode ScoreMode
+	boostMode BoostMode
+	maxBoost  *float32
+	minScore  *float32
+	boost     *float32
+}
+
+// ScoreMode is how the scores of the functions are combined.
+type ScoreMode string
+
+const (
+	// ScoreModeMultiply multiplies the scores (default)
+	ScoreModeMultiply ScoreMode = "multiply"
+
+	// ScoreModeSum sums the scores
+	ScoreModeSum ScoreMode = "sum"
+
+	// ScoreModeAvg averages the scores
+	ScoreModeAvg ScoreMode = "avg"
+
+	// ScoreModeFirst uses the score of the first function with a matching filter
+	ScoreModeFirst ScoreMode = "first"
+
+	// ScoreModeMax uses the maximum score
+	ScoreModeMax ScoreMode = "max"
+
+	// ScoreModeMin uses the minimum score
+	ScoreModeMin ScoreMode = "min"
+)
+
+// BoostMode is how the combined score of the functions is combined with the
+// score of the query.
+type BoostMode string
+
+const (
+	// BoostModeMultiply multiplies the query score and the function score (default)
+	BoostModeMultiply BoostMode = "multiply"
+
+	// BoostModeReplace ignores the query score
+	BoostModeReplace BoostMode = "replace"
+
+	// BoostModeSum sums the query score and the function score
+	BoostModeSum BoostMode = "sum"
+
+	// BoostModeAvg averages the query score and the function score
+	BoostModeAvg BoostMode = "avg"
+
+	// BoostModeMax uses the maximum of the query score and the function score
+	BoostModeMax BoostMode = "max"
+
+	// BoostModeMin uses the minimum of the query score and the function score
+	BoostModeMin BoostMode = "min"
+)
+
+// FunctionScore creates a new compound query of type "function_score",
+// modifying the score of the documents matched by the provided query.
+func FunctionScore(query Mappable) *FunctionScoreQuery {
+	return &FunctionScoreQuery{
+		query: query,
+	}
+}
+
+// Functions adds score functions to the query, such as FieldValueFactor,
+// Gauss, Exp, Linear, RandomScore, Weight or ScriptScore.
+func (q *FunctionScoreQuery) Functions(functions ...Mappable) *FunctionScoreQuery {
+	q.functions = append(q.functions, functions...)
+	return q
+}
+
+// ScoreMode sets how the scores of the functions are combined.
+func (q *FunctionScoreQuery) ScoreMode(mode ScoreMode) *FunctionScoreQuery {
+	q.scoreMode = mode
+	return q
+}
+
+// BoostMode sets how the combined function score is combined with the query
+// score.
+func (q *FunctionScoreQuery) BoostMode(mode BoostMode) *FunctionScoreQuery {
+	q.boostMode = mode
+	return q
+}
+
+// MaxBoost caps the combined function score.
+func (q *FunctionScoreQuery) MaxBoost(b float32) *FunctionScoreQuery {
+	q.maxBoost = &b
+	return q
+}
+
+// MinScore excludes the documents scoring below the provided value.
+func (q *FunctionScoreQuery) MinScore(s float32) *FunctionScoreQuery {
+	q.minScore = &s
+	return q
+}
+
+// Boost sets the boost value of the query.
+func (q *FunctionScoreQuery) Boost(b float32) *FunctionScoreQuery {
+	q.boost = &b
+	return q
+}
+
+// Map returns a map representation of the query, thus implementing the
+// Mappable interface.
+func (q *FunctionScoreQuery) Map() map[string]interface{} {
+	innerMap := make(map[string]interface{})
+
+	if q.query != nil {
+		innerMap["query"] = q.query.Map()
+	}
+	if len(q.functions) > 0 {
+		functions := make([]map[string]interface{}, 0, len(q.functions))
+		for _, function := range q.functions {
+			functions = append(functions, function.Map())
+		}
+		innerMap["functions"] = functions
+	}
+	if q.scoreMode != "" {
+		innerMap["score_mode"] = q.scoreMode
+	}
+	if q.boostMode != "" {
+		innerMap["boost_mode"] = q.boostMode
+	}
+	if q.maxBoost != nil {
+		innerMap["max_boost"] = *q.maxBoost
+	}
+	if q.minScore != nil {
+		innerMap["min_score"] = *q.minScore
+	}
+	if q.boost != nil {
+		innerMap["boost"] = *q.boost
+	}
+
+	return map[string]interface{}{
+		"function_score": innerMap,
+	}
+}
+
+//----------------------------------------------------------------------------//
+
+// scoreFunction holds the options shared by every score function.
+type scoreFunction struct {
+	filter Mappable
+	weight *float32
+}
+
+// mapWith returns the function options merged with the shared options.
+func (f scoreFunction) mapWith(functionMap map[string]interface{}) map[string]interface{} {
+	if f.filter != nil {
+		functionMap["filter"] = f.filter.Map()
+	}
+	if f.weight != nil {
+		functionMap["weight"] = *f.weight
+	}
+
+	return functionMap
+}
+
+//----------------------------------------------------------------------------//
+
+// FieldValueModifier is the function applied to a field value by
+// FieldValueFactor.
+type FieldValueModifier string
+
+const (
+	FieldValueModifierNone       FieldValueModifier = "none"
+	FieldValueModifierLog        FieldValueModifier = "log"
+	FieldValueModifierLog1p      FieldValueModifier = "log1p"
+	FieldValueModifierLog2p      FieldValueModifier = "log2p"
+	FieldValueModifierLn         FieldValueModifier = "ln"
+	FieldValueModifierLn1p       FieldValueModifier = "ln1p"
+	FieldValueModifierLn2p       FieldValueModifier = "ln2p"
+	FieldValueModifierSquare     FieldValueModifier = "square"
+	FieldValueModifierSqrt       FieldValueModifier = "sqrt"
+	FieldValueModifierReciprocal FieldValueModifier = "reciprocal"
+)
+
+// FieldValueFactorFunction represents a score function of type
+// "field_value_factor", as described in
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/query-dsl-function-score-query.html#function-field-value-factor
+type FieldValueFactorFunction struct {
+	scoreFunction
+	field    string
+	factor   *float32
+	modifier FieldValueModifier
+	missing  *float64
+}
+
+// FieldValueFactor creates a new score function of type "field_value_factor",
+// scoring the documents with the value of the provided field.
+func FieldValueFactor(field string) *FieldValueFactorFunction {
+	return &FieldValueFactorFunction{
+		field: field,
+	}
+}
+
+// Factor sets the value the field value is multiplied with.
+func (f *FieldValueFactorFunction) Factor(factor float32) *FieldValueFactorFunction {
+	f.factor = &factor
+	return f
+}
+
+// Modifier sets the function applied to the field value.
+func (f *FieldValueFactorFunction) Modifier(modifier FieldValueModifier) *FieldValueFactorFunction {
+	f.modifier = modifier
+	return f
+}
+
+// Missing sets the value used for documents missing the field.
+func (f *FieldValueFactorFunction) Missing(missing float64) *FieldValueFactorFunction {
+	f.missing = &missing
+	return f
+}
+
+// Filter restricts the function to the documents matching the filter.
+func (f *FieldValueFactorFunction) Filter(filter Mappable) *FieldValueFactorFunction {
+	f.filter = filter
+	return f
+}
+
+// Weight multiplies the score of the function.
+func (f *FieldValueFactorFunction) Weight(weight float32) *FieldValueFactorFunction {
+	f.weight = &weight
+	return f
+}
+
+// Map returns a map representation of the function, thus implementing the
+// Mappable interface.
+func (f *FieldValueFactorFunction) Map() map[string]interface{} {
+	innerMap := map[string]interface{}{
+		"field": f.field,
+	}
+
+	if f.factor != nil {
+		innerMap["factor"] = *f.factor
+	}
+	if f.modifier != "" {
+		innerMap["modifier"] = f.modifier
+	}
+	if f.missing != nil {
+		innerMap["missing"] = *f.missing
+	}
+
+	return f.scoreFunction.mapWith(map[string]interface{}{
+		"field_value_factor": innerMap,
+	})
+}
+
+//----------------------------------------------------------------------------//
+
+// DecayFunction represents a score function of type "gauss", "exp" or
+// "linear", as described in
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/query-dsl-function-score-query.html#function-decay
+type DecayFunction struct {
+	scoreFunction
+	decayType      string
+	field          string
+	origin         interface{}
+	scale          interface{}
+	offset         interface{}
+	decay          *float64
+	multiValueMode string
+}
+
+func newDecayFunction(decayType, field string, origin, scale interface{}) *DecayFunction {
+	return &DecayFunction{
+		decayType: decayType,
+		field:     field,
+		origin:    origin,
+		scale:     scale,
+	}
+}
+
+// Gauss creates a new decay function of type "gauss". The origin is a number,
+// a date (e.g. "now") or a GeoPoint, and the scale the distance from the
+// origin at which the score drops to the decay value, e.g. 10, "7d" or "5km".
+func Gauss(field string, origin, scale interface{}) *DecayFunction {
+	return newDecayFunction("gauss", field, origin, scale)
+}
+
+// Exp creates a new decay function of type "exp", see Gauss for the origin
+// and scale values.
+func Exp(field string, origin, scale interface{}) *DecayFunction {
+	return newDecayFunction("exp", field, origin, scale)
+}
+
+// Linear creates a new decay function of type "linear", see Gauss for the
+// origin and scale values.
+func Linear(field string, origin, scale interface{}) *DecayFunction {
+	return newDecayFunction("linear", field, origin, scale)
+}
+
+// Offset sets the distance from the origin within which the score is not
+// decayed.
+func (f *DecayFunction) Offset(offset interface{}) *DecayFunction {
+	f.offset = offset
+	return f
+}
+
+// Decay sets the score at the scale distance, 0.5 by default.
+func (f *DecayFunction) Decay(decay float64) *DecayFunction {
+	f.decay = &decay
+	return f
+}
+
+// MultiValueMode sets which value is used when the field holds several values,
+// "min", "max", "avg" or "sum".
+func (f *DecayFunction) MultiValueMode(mode string) *DecayFunction {
+	f.multiValueMode = mode
+	return f
+}
+
+// Filter restricts the function to the documents matching the filter.
+func (f *DecayFunction) Filter(filter Mappable) *DecayFunction {
+	f.filter = filter
+	return f
+}
+
+// Weight multiplies the score of the function.
+func (f *DecayFunction) Weight(weight float32) *DecayFunction {
+	f.weight = &weight
+	return f
+}
+
+// Map returns a map representation of the function, thus implementing the
+// Mappable interface.
+func (f *DecayFunction) Map() map[string]interface{} {
+	origin := f.origin
+	if point, ok := origin.(Mappable); ok {
+		origin = point.Map()
+	}
+
+	params := map[string]interface{}{
+		"origin": origin,
+		"scale":  f.scale,
+	}
+
+	if f.offset != nil {
+		params["offset"] = f.offset
+	}
+	if f.decay != nil {
+		params["decay"] = *f.decay
+	}
+
+	innerMap := map[string]interface{}{
+		f.field: params,
+	}
+
+	if f.multiValueMode != "" {
+		innerMap["multi_value_mode"] = f.multiValueMode
+	}
+
+	return f.scoreFunction.mapWith(map[string]interface{}{
+		f.decayType: innerMap,
+	})
+}
+
+//----------------------------------------------------------------------------//
+
+// RandomScoreFunction represents a score function of type "random_score", as
+// described in
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/query-dsl-function-score-query.html#function-random
+type RandomScoreFunction struct {
+	scoreFunction
+	seed  interface{}
+	field string
+}
+
+// RandomScore creates a new score function of type "random_score", scoring
+// the documents uniformly between 0 and 1.
+func RandomScore() *RandomScoreFunction {
+	return &RandomScoreFunction{}
+}
+
+// Seed makes the scores reproducible, documents keep their score for the same
+// seed. The field defaults to "_seq_no" when not set.
+func (f *RandomScoreFunction) Seed(seed interface{}) *RandomScoreFunction {
+	f.seed = seed
+	if f.field == "" {
+		f.field = "_seq_no"
+	}
+	return f
+}
+
+// Field sets the field the seeded scores are computed from.
+func (f *RandomScoreFunction) Field(field string) *RandomScoreFunction {
+	f.field = field
+	return f
+}
+
+// Filter restricts the function to the documents matching the filter.
+func (f *RandomScoreFunction) Filter(filter Mappable) *RandomScoreFunction {
+	f.filter = filter
+	return f
+}
+
+// Weight multiplies the score of the function.
+func (f *RandomScoreFunction) Weight(weight float32) *RandomScoreFunction {
+	f.weight = &weight
+	return f
+}
+
+// Map returns a map representation of the function, thus implementing the
+// Mappable interface.
+func (f *RandomScoreFunction) Map() map[string]interface{} {
+	innerMap := make(map[string]interface{})
+
+	if f.seed != nil {
+		innerMap["seed"] = f.seed
+	}
+	if f.field != "" {
+		innerMap["field"] = f.field
+	}
+
+	return f.scoreFunction.mapWith(map[string]interface{}{
+		"random_score": innerMap,
+	})
+}
+
+//----------------------------------------------------------------------------//
+
+// WeightFunction represents a score function of type "weight", as described in
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/query-dsl-function-score-query.html#function-weight
+type WeightFunction struct {
+	scoreFunction
+}
+
+// Weight creates a new score function of type "weight", scoring the documents
+// with the provided weight. It is usually combined with a filter.
+func Weight(weight float32) *WeightFunction {
+	return &WeightFunction{
+		scoreFunction: scoreFunction{weight: &weight},
+	}
+}
+
+// Filter restricts the function to the documents matching the filter.
+func (f *WeightFunction) Filter(filter Mappable) *WeightFunction {
+	f.filter = filter
+	return f
+}
+
+// Map returns a map representation of the function, thus implementing the
+// Mappable interface.
+func (f *WeightFunction) Map() map[string]interface{} {
+	return f.scoreFunction.mapWith(make(map[string]interface{}))
+}
+
+//----------------------------------------------------------------------------//
+
+// ScriptScoreFunction represents a score function of type "script_score", as
+// described in
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/query-dsl-function-score-query.html#function-script-score
+type ScriptScoreFunction struct {
+	scoreFunction
+	source string
+	lang   string
+	params map[string]interface{}
+}
+
+// ScriptScore creates a new score function of type "script_score", scoring the
+// documents with the provided script source.
+func ScriptScore(source string) *ScriptScoreFunction {
+	return &ScriptScoreFunction{
+		source: source,
+	}
+}
+
+// Lang sets the language of the script, "painless" by default.
+func (f *ScriptScoreFunction) Lang(lang string) *ScriptScoreFunction {
+	f.lang = lang
+	return f
+}
+
+// Params sets the parameters of the script.
+func (f *ScriptScoreFunction) Params(params map[string]interface{}) *ScriptScoreFunction {
+	f.params = params
+	return f
+}
+
+// Filter restricts the function to the documents matching the filter.
+func (f *ScriptScoreFunction) Filter(filter Mappable) *ScriptScoreFunction {
+	f.filter = filter
+	return f
+}
+
+// Weight multiplies the score of the function.
+func (f *ScriptScoreFunction) Weight(weight float32) *ScriptScoreFunction {
+	f.weight = &weight
+	return f
+}
+
+// Map returns a map representation of the function, thus implementing the
+// Mappable interface.
+func (f *ScriptScoreFunction) Map() map[string]interface{} {
+	script := map[string]interface{}{
+		"source": f.source,
+	}
+
+	if f.lang != "" {
+		script["lang"] = f.lang
+	}
+	if len(f.params) > 0 {
+		script["params"] = f.params
+	}
+
+	return f.scoreFunction.mapWith(map[string]interface{}{
+		"script_score": map[string]interface{}{
+			"script": script,
+		},
+	})
+}
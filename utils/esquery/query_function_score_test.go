@@ -0,0 +1,116 @@
+package esquery
+
+import "testing"
+
+func TestFunctionScore(t *testing.T) {
+	runMapTests(t, []mapTest{
+		{
+			"function_score with the query only",
+			FunctionScore(MatchAll()),
+			`{"function_score": {"query": {"match_all": {}}}}`,
+		},
+		{
+			"function_score with all options",
+			FunctionScore(Term("gender", "female")).
+				Functions(Weight(2), RandomScore()).
+				ScoreMode(ScoreModeSum).
+				BoostMode(BoostModeReplace).
+				MaxBoost(10).
+				MinScore(0.5).
+				Boost(1.5),
+			`{"function_score": {
+				"query": {"term": {"gender": {"value": "female"}}},
+				"functions": [{"weight": 2}, {"random_score": {}}],
+				"score_mode": "sum",
+				"boost_mode": "replace",
+				"max_boost": 10,
+				"min_score": 0.5,
+				"boost": 1.5
+			}}`,
+		},
+	})
+}
+
+func TestScoreFunctions(t *testing.T) {
+	runMapTests(t, []mapTest{
+		{
+			"gauss with a geo point origin",
+			Gauss("location", GeoPoint{Lat: -6.2, Lon: 106.8}, "5km"),
+			`{"gauss": {"location": {"origin": {"lat": -6.2, "lon": 106.8}, "scale": "5km"}}}`,
+		},
+		{
+			"exp with all options",
+			Exp("last_active_at", "now", "7d").
+				Offset("1d").
+				Decay(0.25).
+				MultiValueMode("max").
+				Filter(Term("active", true)).
+				Weight(2),
+			`{
+				"exp": {
+					"last_active_at": {"origin": "now", "scale": "7d", "offset": "1d", "decay": 0.25},
+					"multi_value_mode": "max"
+				},
+				"filter": {"term": {"active": {"value": true}}},
+				"weight": 2
+			}`,
+		},
+		{
+			"linear with a numeric origin",
+			Linear("age", 30, 10),
+			`{"linear": {"age": {"origin": 30, "scale": 10}}}`,
+		},
+		{
+			"field_value_factor with default options",
+			FieldValueFactor("shared_interests"),
+			`{"field_value_factor": {"field": "shared_interests"}}`,
+		},
+		{
+			"field_value_factor with all options",
+			FieldValueFactor("shared_interests").
+				Factor(1.5).
+				Modifier(FieldValueModifierLog1p).
+				Missing(1).
+				Filter(Term("gender", "male")).
+				Weight(0.5),
+			`{
+				"field_value_factor": {"field": "shared_interests", "factor": 1.5, "modifier": "log1p", "missing": 1},
+				"filter": {"term": {"gender": {"value": "male"}}},
+				"weight": 0.5
+			}`,
+		},
+		{
+			"script_score with the source only",
+			ScriptScore("_score * doc['popularity'].value"),
+			`{"script_score": {"script": {"source": "_score * doc['popularity'].value"}}}`,
+		},
+		{
+			"script_score with all options",
+			ScriptScore("_score * params.factor").
+				Lang("painless").
+				Params(map[string]interface{}{"factor": 2}).
+				Filter(Term("verified", true)).
+				Weight(4),
+			`{
+				"script_score": {"script": {"source": "_score * params.factor", "lang": "painless", "params": {"factor": 2}}},
+				"filter": {"term": {"verified": {"value": true}}},
+				"weight": 4
+			}`,
+		},
+		{
+			"weight with a filter",
+			Weight(3).Filter(Term("premium", true)),
+			`{"filter": {"term": {"premium": {"value": true}}}, "weight": 3}`,
+		},
+		{
+			"random_score with a seed defaults the field",
+			RandomScore().Seed(42),
+			`{"random_score": {"seed": 42, "field": "_seq_no"}}`,
+		},
+		{
+			"random_score with a seed and a field",
+			RandomScore().Field("user_id").Seed("viewer-1").Weight(0.5),
+			`{"random_score": {"seed": "viewer-1", "field": "user_id"}, "weight": 0.5}`,
+		},
+	})
+}
@@ -0,0 +1,323 @@
+package esquery
+
+// GeoPoint is a location given by its latitude and longitude, as accepted by
+// the geo queries, sorts and aggregations.
+type GeoPoint struct {
+	Lat float64
+	Lon float64
+}
+
+// Map returns a map representation of the point, thus implementing the
+// Mappable interface.
+func (p GeoPoint) Map() map[string]interface{} {
+	return map[string]interface{}{
+		"lat": p.Lat,
+		"lon": p.Lon,
+	}
+}
+
+//----------------------------------------------------------------------------//
+
+// GeoDistanceQuery represents a query of type "geo_distance", as described in:
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/query-dsl-geo-distance-query.html
+type GeoDistanceQuery struct {
+	field            string
+	point            GeoPoint
+	distance         string
+	distanceType     string
+	validationMethod string
+	ignoreUnmapped   *bool
+	name             string
+}
+
+// GeoDistance creates a new query of type "geo_distance", matching documents
+// whose field is within the distance (e.g. "10km") of the provided point.
+func GeoDistance(field string, point GeoPoint, distance string) *GeoDistanceQuery {
+	return &GeoDistanceQuery{
+		field:    field,
+		point:    point,
+		distance: distance,
+	}
+}
+
+// DistanceType sets how the distance is computed, "arc" (default) or "plane".
+func (q *GeoDistanceQuery) DistanceType(distanceType string) *GeoDistanceQuery {
+	q.distanceType = distanceType
+	return q
+}
+
+// ValidationMethod sets how invalid points are handled, "STRICT" (default),
+// "IGNORE_MALFORMED" or "COERCE".
+func (q *GeoDistanceQuery) ValidationMethod(method string) *GeoDistanceQuery {
+	q.validationMethod = method
+	return q
+}
+
+// IgnoreUnmapped sets whether to match no documents instead of failing when
+// the field is not mapped.
+func (q *GeoDistanceQuery) IgnoreUnmapped(b bool) *GeoDistanceQuery {
+	q.ignoreUnmapped = &b
+	return q
+}
+
+// Name sets the name of the query, reported in the matched queries of a hit.
+func (q *GeoDistanceQuery) Name(name string) *GeoDistanceQuery {
+	q.name = name
+	return q
+}
+
+// Map returns a map representation of the query, thus implementing the
+// Mappable interface.
+func (q *GeoDistanceQuery) Map() map[string]interface{} {
+	innerMap := map[string]interface{}{
+		"distance": q.distance,
+		q.field:    q.point.Map(),
+	}
+
+	if q.distanceType != "" {
+		innerMap["distance_type"] = q.distanceType
+	}
+	if q.validationMethod != "" {
+		innerMap["validation_method"] = q.validationMethod
+	}
+	if q.ignoreUnmapped != nil {
+		innerMap["ignore_unmapped"] = *q.ignoreUnmapped
+	}
+	if q.name != "" {
+		innerMap["_name"] = q.name
+	}
+
+	return map[string]interface{}{
+		"geo_distance": innerMap,
+	}
+}
+
+//----------------------------------------------------------------------------//
+
+// GeoBoundingBoxQuery represents a query of type "geo_bounding_box", as
+// described in:
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/query-dsl-geo-bounding-box-query.html
+type GeoBoundingBoxQuery struct {
+	field            string
+	topLeft          GeoPoint
+	bottomRight      GeoPoint
+	validationMethod string
+	ignoreUnmapped   *bool
+	name             string
+}
+
+// GeoBoundingBox creates a new query of type "geo_bounding_box", matching
+// documents whose field is within the box of the provided corners.
+func GeoBoundingBox(field string, topLeft, bottomRight GeoPoint) *GeoBoundingBoxQuery {
+	return &GeoBoundingBoxQuery{
+		field:       field,
+		topLeft:     topLeft,
+		bottomRight: bottomRight,
+	}
+}
+
+// ValidationMethod sets how invalid points are handled, "STRICT" (default),
+// "IGNORE_MALFORMED" or "COERCE".
+func (q *GeoBoundingBoxQuery) ValidationMethod(method string) *GeoBoundingBoxQuery {
+	q.validationMethod = method
+	return q
+}
+
+// IgnoreUnmapped sets whether to match no documents instead of failing when
+// the field is not mapped.
+func (q *GeoBoundingBoxQuery) IgnoreUnmapped(b bool) *GeoBoundingBoxQuery {
+	q.ignoreUnmapped = &b
+	return q
+}
+
+// Name sets the name of the query, reported in the matched queries of a hit.
+func (q *GeoBoundingBoxQuery) Name(name string) *GeoBoundingBoxQuery {
+	q.name = name
+	return q
+}
+
+// Map returns a map representation of the query, thus implementing the
+// Mappable interface.
+func (q *GeoBoundingBoxQuery) Map() map[string]interface{} {
+	innerMap := map[string]interface{}{
+		q.field: map[string]interface{}{
+			"top_left":     q.topLeft.Map(),
+			"bottom_right": q.bottomRight.Map(),
+		},
+	}
+
+	if q.validationMethod != "" {
+		innerMap["validation_method"] = q.validationMethod
+	}
+	if q.ignoreUnmapped != nil {
+		innerMap["ignore_unmapped"] = *q.ignoreUnmapped
+	}
+	if q.name != "" {
+		innerMap["_name"] = q.name
+	}
+
+	return map[string]interface{}{
+		"geo_bounding_box": innerMap,
+	}
+}
+
+//----------------------------------------------------------------------------//
+
+// GeoPolygonQuery represents a query of type "geo_polygon", as described in:
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/query-dsl-geo-polygon-query.html
+type GeoPolygonQuery struct {
+	field            string
+	points           []GeoPoint
+	validationMethod string
+	ignoreUnmapped   *bool
+	name             string
+}
+
+// GeoPolygon creates a new query of type "geo_polygon", matching documents
+// whose field is within the polygon of the provided points.
+func GeoPolygon(field string, points ...GeoPoint) *GeoPolygonQuery {
+	return &GeoPolygonQuery{
+		field:  field,
+		points: points,
+	}
+}
+
+// ValidationMethod sets how invalid points are handled, "STRICT" (default),
+// "IGNORE_MALFORMED" or "COERCE".
+func (q *GeoPolygonQuery) ValidationMethod(method string) *GeoPolygonQuery {
+	q.validationMethod = method
+	return q
+}
+
+// IgnoreUnmapped sets whether to match no documents instead of failing when
+// the field is not mapped.
+func (q *GeoPolygonQuery) IgnoreUnmapped(b bool) *GeoPolygonQuery {
+	q.ignoreUnmapped = &b
+	return q
+}
+
+// Name sets the name of the query, reported in the matched queries of a hit.
+func (q *GeoPolygonQuery) Name(name string) *GeoPolygonQuery {
+	q.name = name
+	return q
+}
+
+// Map returns a map representation of the query, thus implementing the
+// Mappable interface.
+func (q *GeoPolygonQuery) Map() map[string]interface{} {
+	points := make([]map[string]interface{}, 0, len(q.points))
+	for _, point := range q.points {
+		points = append(points, point.Map())
+	}
+
+	innerMap := map[string]interface{}{
+		q.field: map[string]interface{}{
+			"points": points,
+		},
+	}
+
+	if q.validationMethod != "" {
+		innerMap["validation_method"] = q.validationMethod
+	}
+	if q.ignoreUnmapped != nil {
+		innerMap["ignore_unmapped"] = *q.ignoreUnmapped
+	}
+	if q.name != "" {
+		innerMap["_name"] = q.name
+	}
+
+	return map[string]interface{}{
+		"geo_polygon": innerMap,
+	}
+}
+
+//----------------------------------------------------------------------------//
+
+// GeoDistanceSorter represents a sort option of type "_geo_distance", as
+// described in:
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/sort-search-results.html#geo-sorting
+type GeoDistanceSorter struct {
+	field          string
+	points         []GeoPoint
+	order          Order
+	unit           string
+	mode           string
+	distanceType   string
+	ignoreUnmapped *bool
+}
+
+// GeoDistanceSort creates a new sort option of type "_geo_distance", sorting
+// by the distance of the field to the provided points. Use it with
+// SearchRequest.SortByGeoDistance, or add its map to a Sort.
+func GeoDistanceSort(field string, order Order, points ...GeoPoint) *GeoDistanceSorter {
+	return &GeoDistanceSorter{
+		field:  field,
+		points: points,
+		order:  order,
+	}
+}
+
+// Unit sets the unit of the sort values, e.g. "km" (default "m").
+func (s *GeoDistanceSorter) Unit(unit string) *GeoDistanceSorter {
+	s.unit = unit
+	return s
+}
+
+// Mode sets which distance is used when the field holds several points,
+// "min", "max", "median" or "avg".
+func (s *GeoDistanceSorter) Mode(mode string) *GeoDistanceSorter {
+	s.mode = mode
+	return s
+}
+
+// DistanceType sets how the distance is computed, "arc" (default) or "plane".
+func (s *GeoDistanceSorter) DistanceType(distanceType string) *GeoDistanceSorter {
+	s.distanceType = distanceType
+	return s
+}
+
+// IgnoreUnmapped sets whether to sort the documents last instead of failing
+// when the field is not mapped.
+func (s *GeoDistanceSorter) IgnoreUnmapped(b bool) *GeoDistanceSorter {
+	s.ignoreUnmapped = &b
+	return s
+}
+
+// Map returns a map representation of the sort option, thus implementing the
+// Mappable interface.
+func (s *GeoDistanceSorter) Map() map[string]interface{} {
+	var points interface{}
+	if len(s.points) == 1 {
+		points = s.points[0].Map()
+	} else {
+		list := make([]map[string]interface{}, 0, len(s.points))
+		for _, point := range s.points {
+			list = append(list, point.Map())
+		}
+		points = list
+	}
+
+	innerMap := map[string]interface{}{
+		s.field: points,
+	}
+
+	if s.order != "" {
+		innerMap["order"] = s.order
+	}
+	if s.unit != "" {
+		innerMap["unit"] = s.unit
+	}
+	if s.mode != "" {
+		innerMap["mode"] = s.mode
+	}
+	if s.distanceType != "" {
+		innerMap["distance_type"] = s.distanceType
+	}
+	if s.ignoreUnmapped != nil {
+		innerMap["ignore_unmapped"] = *s.ignoreUnmapped
+	}
+
+	return map[string]interface{}{
+		"_geo_distance": innerMap,
+	}
+}
@@ -0,0 +1,83 @@
+package esquery
+
+import "testing"
+
+func TestGeoQueries(t *testing.T) {
+	runMapTests(t, []mapTest{
+		{
+			"geo_distance with default options",
+			GeoDistance("location", GeoPoint{Lat: 40.7, Lon: -74}, "10km"),
+			`{"geo_distance": {"distance": "10km", "location": {"lat": 40.7, "lon": -74}}}`,
+		},
+		{
+			"geo_distance with all options",
+			GeoDistance("location", GeoPoint{Lat: 40.7, Lon: -74}, "10km").
+				DistanceType("plane").
+				ValidationMethod("COERCE").
+				IgnoreUnmapped(true).
+				Name("nearby"),
+			`{"geo_distance": {
+				"distance": "10km",
+				"location": {"lat": 40.7, "lon": -74},
+				"distance_type": "plane",
+				"validation_method": "COERCE",
+				"ignore_unmapped": true,
+				"_name": "nearby"
+			}}`,
+		},
+		{
+			"geo_bounding_box",
+			GeoBoundingBox("location", GeoPoint{Lat: 40.73, Lon: -74.1}, GeoPoint{Lat: 40.01, Lon: -71.12}).
+				IgnoreUnmapped(false).
+				Name("box"),
+			`{"geo_bounding_box": {
+				"location": {
+					"top_left": {"lat": 40.73, "lon": -74.1},
+					"bottom_right": {"lat": 40.01, "lon": -71.12}
+				},
+				"ignore_unmapped": false,
+				"_name": "box"
+			}}`,
+		},
+		{
+			"geo_polygon",
+			GeoPolygon("location",
+				GeoPoint{Lat: 40, Lon: -70},
+				GeoPoint{Lat: 30, Lon: -80},
+				GeoPoint{Lat: 20, Lon: -90},
+			).ValidationMethod("IGNORE_MALFORMED"),
+			`{"geo_polygon": {
+				"location": {"points": [
+					{"lat": 40, "lon": -70},
+					{"lat": 30, "lon": -80},
+					{"lat": 20, "lon": -90}
+				]},
+				"validation_method": "IGNORE_MALFORMED"
+			}}`,
+		},
+	})
+}
+
+func TestGeoDistanceSort(t *testing.T) {
+	runMapTests(t, []mapTest{
+		{
+			"one point is not a list",
+			GeoDistanceSort("location", OrderAsc, GeoPoint{Lat: 40.7, Lon: -74}).Unit("km"),
+			`{"_geo_distance": {"location": {"lat": 40.7, "lon": -74}, "order": "asc", "unit": "km"}}`,
+		},
+		{
+			"several points",
+			GeoDistanceSort("location", OrderDesc, GeoPoint{Lat: 40.7, Lon: -74}, GeoPoint{Lat: 52.5, Lon: 13.4}).
+				Mode("min").
+				DistanceType("arc").
+				IgnoreUnmapped(true),
+			`{"_geo_distance": {
+				"location": [{"lat": 40.7, "lon": -74}, {"lat": 52.5, "lon": 13.4}],
+				"order": "desc",
+				"mode": "min",
+				"distance_type": "arc",
+				"ignore_unmapped": true
+			}}`,
+		},
+	})
+}
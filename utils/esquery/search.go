@@ -222,6 +222,13 @@ func (req *SearchRequest) SortByName(name string, order Order) *SearchRequest {
 	return req
 }
 
+// SortByGeoDistance adds a sort by the distance of a geo point field.
+func (req *SearchRequest) SortByGeoDistance(sorter *GeoDistanceSorter) *SearchRequest {
+	req.sort = append(req.sort, sorter.Map())
+
+	return req
+}
+
 // SearchAfter retrieve the sorted result
 func (req *SearchRequest) SearchAfter(s ...interface{}) *SearchRequest {
 	req.searchAfter = append(req.searchAfter, s...)
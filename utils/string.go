@@ -42,3 +42,15 @@ func ToCamelCase(input string) string {
 
 	return string(runes)
 }
+
+// ToPostgresTextArray formats values as a postgres text[] literal
+func ToPostgresTextArray(values []string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+	quoted := make([]string, 0, len(values))
+	for _, value := range values {
+		quoted = append(quoted, `"`+replacer.Replace(value)+`"`)
+	}
+
+	return "{" + strings.Join(quoted, ",") + "}"
+}